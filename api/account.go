@@ -6,6 +6,8 @@ import (
 	"net/http"
 
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/policy"
 	"github.com/HzTTT/simple_bank/token"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -66,16 +68,23 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized,errorResponse(err))
+	if err := policy.Authorize(authPayload, policy.ViewAccount, account.Owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
 	}
 	ctx.JSON(http.StatusOK,account)
 }
 
 type listAccountRequest struct {
-	PageID int32 `form:"page_id" binding:"required,min=1"`
+	PageToken string `form:"page_token"`
 	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	// bankers and admins may list the accounts of another user
+	Owner string `form:"owner" binding:"omitempty,alphanum"`
+}
+
+type listAccountResponse struct {
+	Accounts      []db.Account `json:"accounts"`
+	NextPageToken string       `json:"next_page_token"`
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
@@ -86,11 +95,27 @@ func (server *Server) listAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	owner := authPayload.Username
+	if req.Owner != "" {
+		owner = req.Owner
+	}
+	if err := policy.Authorize(authPayload, policy.ViewAccount, owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	scope := pagination.AccountsScope(owner)
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.PageToken)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		return
+	}
 
 	arg := db.ListAccountsParams{
-		Owner: authPayload.Username,
-		Limit: req.PageSize,
-		Offset: (req.PageID-1)*req.PageSize,
+		Owner: owner,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID: cursorID,
+		PageLimit: req.PageSize+1,
 	}
 	accounts, err := server.store.ListAccounts(ctx,arg)
 	if err != nil {
@@ -98,5 +123,113 @@ func (server *Server) listAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK,accounts)
-}
\ No newline at end of file
+	accounts, nextPageToken := pagination.NextPage(server.pageTokens, scope, accounts, req.PageSize, func(account db.Account) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: account.CreatedAt, ID: account.ID}
+	})
+	if accounts == nil {
+		accounts = []db.Account{}
+	}
+
+	ctx.JSON(http.StatusOK,listAccountResponse{
+		Accounts: accounts,
+		NextPageToken: nextPageToken,
+	})
+}
+
+type updateAccountStatusRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusActive)
+}
+
+func (server *Server) closeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusClosed)
+}
+
+func (server *Server) updateAccountStatus(ctx *gin.Context, status string) {
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	action, owner := policy.AccountStatusAction(status, account.Owner)
+	if err := policy.Authorize(authPayload, action, owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	account, err = server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: req.ID,
+		Status:    status,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInvalidStatusTransition):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountBalanceNotZero):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+type updateOverdraftLimitURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+// updateOverdraftLimit sets how far below zero the balance of an account
+// may go.
+func (server *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri updateOverdraftLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.UpdateAccountOverdraftLimit(ctx, db.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *req.OverdraftLimit,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
@@ -13,6 +13,8 @@ import (
 
 	mockdb "github.com/HzTTT/simple_bank/db/mock"
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/token"
 	"github.com/HzTTT/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -25,7 +27,7 @@ func TestGetAccountAPI(t *testing.T) {
 	newRequest := func(testCase *TestCase,server *Server) (request *http.Request, err error) {
 		url := fmt.Sprintf("/account/%d", testCase.request["accountID"])
 		request, err = http.NewRequest(http.MethodGet, url, nil)
-		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user.Username,time.Minute)
+		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user.Username,util.DepositorRole,time.Minute)
 		return
 	}
 	testCases := []*TestCase{
@@ -63,7 +65,29 @@ func TestGetAccountAPI(t *testing.T) {
 			newRequest: func(testCase *TestCase, server *Server) (request *http.Request, err error) {
 				url := fmt.Sprintf("/account/%d", testCase.request["accountID"])
 				request, err = http.NewRequest(http.MethodGet, url, nil)
-				addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,"unauthorzed_user",time.Minute)
+				addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,"unauthorzed_user",util.DepositorRole,time.Minute)
+				return
+			},
+		},
+		{
+			name: "BankerViewsAccount",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+				url := fmt.Sprintf("/account/%d", testCase.request["accountID"])
+				request, err = http.NewRequest(http.MethodGet, url, nil)
+				addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
 				return
 			},
 		},
@@ -146,7 +170,7 @@ func TestCreateAccountAPI(t *testing.T) {
 		require.NoError(t, err)
 		url := "/account"
 		request, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
-		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user.Username,time.Minute)
+		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user.Username,util.DepositorRole,time.Minute)
 		return
 	}
 	testCases := []*TestCase{
@@ -220,36 +244,43 @@ func TestCreateAccountAPI(t *testing.T) {
 
 func TestListAcoountsAPI(t *testing.T) {
 	user, _ := randomUser(t)
-	
 
 	pageSize := int32(5)
-	pageID := int32(5)
-	accounts := make([]db.Account, pageSize)
-	for i := int32(0); i < pageSize; i++ {
+	accounts := make([]db.Account, pageSize+1)
+	for i := range accounts {
 		accounts[i] = randomAccount(user.Username)
 	}
+	cursor := pagination.Cursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        util.RandomInt(1, 1000),
+	}
+
 	newRequest := func(testCase *TestCase,server *Server) (request *http.Request, err error) {
-		url := fmt.Sprintf("/account?page_id=%d&page_size=%d", testCase.request["page_id"], testCase.request["page_size"])
+		url := fmt.Sprintf("/account?page_size=%d", testCase.request["page_size"])
+		if pageToken, ok := testCase.request["page_token"]; ok {
+			url += fmt.Sprintf("&page_token=%s", pageToken)
+		}
+		if owner, ok := testCase.request["owner"]; ok {
+			url += fmt.Sprintf("&owner=%s", owner)
+		}
 		request, err = http.NewRequest(http.MethodGet, url, nil)
-		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user.Username,time.Minute)
-
-		/* request, err = http.NewRequest(http.MethodGet, url, nil)
-		require.NoError(t, err)
-
-		// Add query parameters to request URL
-		q := request.URL.Query()
-		q.Add("page_id", fmt.Sprintf("%d", testCase.request["page_id"]))
-		q.Add("page_size", fmt.Sprintf("%d", testCase.request["page_size"]))
-		request.URL.RawQuery = q.Encode() */
-
+		username, role := user.Username, util.DepositorRole
+		if caller, ok := testCase.request["caller"].(*token.Payload); ok {
+			username, role = caller.Username, caller.Role
+		}
+		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,username,role,time.Minute)
 		return
 	}
 
+	newPageTokenRequest := func(testCase *TestCase,server *Server) (request *http.Request, err error) {
+		testCase.request["page_token"] = server.pageTokens.Encode(pagination.AccountsScope(user.Username), cursor)
+		return newRequest(testCase, server)
+	}
+
 	testCases := []*TestCase{
 		{
 			name: "OK",
 			request: gin.H{
-				"page_id":   pageID,
 				"page_size": pageSize,
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
@@ -257,24 +288,49 @@ func TestListAcoountsAPI(t *testing.T) {
 					ListAccounts(
 						gomock.Any(),
 						gomock.Eq(db.ListAccountsParams{
-							Offset: (pageID - 1) * pageSize,
-							Limit:  pageSize,
 							Owner: user.Username,
+							PageLimit: pageSize + 1,
 						})).
 					Times(1).
-					Return(accounts, nil)
+					Return(accounts[:pageSize], nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccounts(t, recorder.Body, accounts)
+				rsp := requireBodyMatchAccounts(t, recorder.Body, accounts[:pageSize])
+				require.Empty(t, rsp.NextPageToken)
 			},
 			newRequest: newRequest,
 		},
 		{
-			name: "InvalidID",
+			name: "BankerListsOtherOwner",
 			request: gin.H{
-				"page_id":   int32(0),
 				"page_size": pageSize,
+				"owner":     user.Username,
+				"caller":    &token.Payload{Username: "banker", Role: util.BankerRole},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(
+						gomock.Any(),
+						gomock.Eq(db.ListAccountsParams{
+							Owner:     user.Username,
+							PageLimit: pageSize + 1,
+						})).
+					Times(1).
+					Return(accounts[:pageSize], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:pageSize])
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "DepositorListsOtherOwner",
+			request: gin.H{
+				"page_size": pageSize,
+				"owner":     user.Username,
+				"caller":    &token.Payload{Username: "depositor", Role: util.DepositorRole},
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -282,14 +338,31 @@ func TestListAcoountsAPI(t *testing.T) {
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 			newRequest: newRequest,
 		},
 		{
-			name: "InternalError",
+			name: "HasNextPage",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				rsp := requireBodyMatchAccounts(t, recorder.Body, accounts[:pageSize])
+				require.NotEmpty(t, rsp.NextPageToken)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "WithPageToken",
 			request: gin.H{
-				"page_id":   pageID,
 				"page_size": pageSize,
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
@@ -297,11 +370,60 @@ func TestListAcoountsAPI(t *testing.T) {
 					ListAccounts(
 						gomock.Any(),
 						gomock.Eq(db.ListAccountsParams{
-							Offset: (pageID - 1) * pageSize,
-							Limit:  pageSize,
 							Owner: user.Username,
+							CursorCreatedAt: sql.NullTime{Time: cursor.CreatedAt, Valid: true},
+							CursorID: sql.NullInt64{Int64: cursor.ID, Valid: true},
+							PageLimit: pageSize + 1,
 						})).
 					Times(1).
+					Return(accounts[:1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:1])
+			},
+			newRequest: newPageTokenRequest,
+		},
+		{
+			name: "InvalidPageToken",
+			request: gin.H{
+				"page_size": pageSize,
+				"page_token": "invalid",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidPageSize",
+			request: gin.H{
+				"page_size": int32(100),
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
 					Return([]db.Account{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -314,12 +436,183 @@ func TestListAcoountsAPI(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestUpdateAccountStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	newRequest := func(action string, role string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+			url := fmt.Sprintf("/account/%d/%s", testCase.request["accountID"], action)
+			request, err = http.NewRequest(http.MethodPost, url, nil)
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, role, time.Minute)
+			return
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "FreezeOK",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil),
+					store.EXPECT().
+						UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{
+							AccountID: account.ID,
+							Status:    db.AccountStatusFrozen,
+						})).
+						Times(1).
+						Return(frozen, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				requireBodyMatchAccount(t, recorder.Body, frozen)
+			},
+			newRequest: newRequest("freeze", util.DepositorRole),
+		},
+		{
+			name: "UnfreezeOK",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil),
+					store.EXPECT().
+						UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{
+							AccountID: account.ID,
+							Status:    db.AccountStatusActive,
+						})).
+						Times(1).
+						Return(account, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest("unfreeze", util.BankerRole),
+		},
+		{
+			// owners can't lift a freeze, or they could undo one imposed by the bank
+			name: "OwnerUnfreeze",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest("unfreeze", util.DepositorRole),
+		},
+		{
+			name: "BankerFreezeOthersAccount",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = "other"
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{
+						AccountID: account.ID,
+						Status:    db.AccountStatusFrozen,
+					})).
+					Times(1).
+					Return(other, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest("freeze", util.BankerRole),
+		},
+		{
+			name: "CloseBalanceNotZero",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+			newRequest: newRequest("close", util.DepositorRole),
+		},
+		{
+			name: "InvalidTransition",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+			newRequest: newRequest("unfreeze", util.BankerRole),
+		},
+		{
+			name: "UnauthorizedUser",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = "other"
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest("close", util.DepositorRole),
+		},
+		{
+			name: "NotFound",
+			request: gin.H{
+				"accountID": account.ID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest("freeze", util.DepositorRole),
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func randomAccount(owmer string) db.Account {
 	return db.Account{
 		ID:       util.RandomInt(1, 1000),
 		Owner:    owmer,
 		Balance:  util.RandMoney(),
 		Currency: util.RandCurrency(),
+		Status:   db.AccountStatusActive,
 	}
 }
 
@@ -332,12 +625,164 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	require.Equal(t, account, gotAccount)
 }
 
-func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
+func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) listAccountResponse {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccounts []db.Account
-	err = json.Unmarshal(data, &gotAccounts)
+	var rsp listAccountResponse
+	err = json.Unmarshal(data, &rsp)
 	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
+	require.Equal(t, accounts, rsp.Accounts)
+	return rsp
+}
+
+func TestUpdateOverdraftLimitAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	newRequest := func(accountID int64, role string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			data, err := json.Marshal(testCase.request)
+			if err != nil {
+				return nil, err
+			}
+			url := fmt.Sprintf("/account/%d/overdraft_limit", accountID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandOwner(), role, time.Minute)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"overdraft_limit": 500,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				updated := account
+				updated.OverdraftLimit = 500
+				store.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Eq(db.UpdateAccountOverdraftLimitParams{
+						ID:             account.ID,
+						OverdraftLimit: 500,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				updated := account
+				updated.OverdraftLimit = 500
+				requireBodyMatchAccount(t, recorder.Body, updated)
+			},
+			newRequest: newRequest(account.ID, util.BankerRole),
+		},
+		{
+			name: "ZeroLimit",
+			request: gin.H{
+				"overdraft_limit": 0,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Eq(db.UpdateAccountOverdraftLimitParams{
+						ID:             account.ID,
+						OverdraftLimit: 0,
+					})).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.AdminRole),
+		},
+		{
+			name: "Depositor",
+			request: gin.H{
+				"overdraft_limit": 500,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.DepositorRole),
+		},
+		{
+			name: "NegativeLimit",
+			request: gin.H{
+				"overdraft_limit": -1,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.BankerRole),
+		},
+		{
+			name:    "MissingLimit",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.BankerRole),
+		},
+		{
+			name: "NotFound",
+			request: gin.H{
+				"overdraft_limit": 500,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.BankerRole),
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"overdraft_limit": 500,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest(account.ID, util.BankerRole),
+		},
+		{
+			name: "InvalidID",
+			request: gin.H{
+				"overdraft_limit": 500,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(0, util.BankerRole),
+		},
+	}
+
+	runTestCases(t, testCases)
 }
@@ -0,0 +1,458 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/apikey"
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomAPIKey(t *testing.T, username string, scopes ...string) (string, db.ApiKey) {
+	key, hash, err := apikey.Generate()
+	require.NoError(t, err)
+
+	apiKey := db.ApiKey{
+		ID:        uuid.New(),
+		Username:  username,
+		Name:      util.RandomString(6),
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	return key, apiKey
+}
+
+func addBearerToken(request *http.Request, credential string) {
+	request.Header.Set(authorizationKey, fmt.Sprintf("%s %s", authorizationTypeBearer, credential))
+}
+
+func TestCreateAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/api_keys", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"name":            "reports",
+				"scopes":          []string{"accounts:read", "transfers:read"},
+				"expires_in_days": 30,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "reports", arg.Name)
+						require.Equal(t, []string{"accounts:read", "transfers:read"}, arg.Scopes)
+						require.WithinDuration(t, time.Now().AddDate(0, 0, 30), arg.ExpiresAt, time.Second)
+						return db.ApiKey{
+							ID:        arg.ID,
+							Username:  arg.Username,
+							Name:      arg.Name,
+							KeyHash:   arg.KeyHash,
+							Scopes:    arg.Scopes,
+							ExpiresAt: arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "key_hash")
+
+				var rsp createAPIKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, apikey.IsAPIKey(rsp.Key))
+				require.Equal(t, "reports", rsp.APIKey.Name)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "DefaultExpiry",
+			request: gin.H{
+				"name":   "reports",
+				"scopes": []string{"accounts:read"},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.WithinDuration(t, time.Now().AddDate(0, 0, apikey.DefaultLifetimeDays), arg.ExpiresAt, time.Second)
+						return db.ApiKey{ID: arg.ID, Scopes: arg.Scopes}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "NoScopes",
+			request: gin.H{
+				"name":   "reports",
+				"scopes": []string{},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "UnsupportedScope",
+			request: gin.H{
+				"name":   "reports",
+				"scopes": []string{"users:write"},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ExpiryTooLong",
+			request: gin.H{
+				"name":            "reports",
+				"scopes":          []string{"accounts:read"},
+				"expires_in_days": 366,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"name":   "reports",
+				"scopes": []string{"accounts:read"},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestListAPIKeysAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	_, apiKey1 := randomAPIKey(t, user.Username, "accounts:read")
+	_, apiKey2 := randomAPIKey(t, user.Username, "transfers:write")
+	apiKeys := []db.ApiKey{apiKey1, apiKey2}
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		request := httptest.NewRequest(http.MethodGet, "/api_keys", nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAPIKeys(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(apiKeys, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "key_hash")
+
+				var rsp listAPIKeysResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.APIKeys, len(apiKeys))
+				for i, apiKey := range apiKeys {
+					require.Equal(t, apiKey.ID, rsp.APIKeys[i].ID)
+					require.Equal(t, apiKey.Scopes, rsp.APIKeys[i].Scopes)
+				}
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAPIKeys(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	_, apiKey := randomAPIKey(t, user.Username, "accounts:read")
+
+	newRequest := func(id string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			request := httptest.NewRequest(http.MethodPost, "/api_keys/"+id+"/revoke", nil)
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				revoked := apiKey
+				revoked.IsRevoked = true
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(db.RevokeAPIKeyParams{ID: apiKey.ID, Username: user.Username})).
+					Times(1).
+					Return(revoked, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp apiKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, apiKey.ID, rsp.ID)
+				require.True(t, rsp.IsRevoked)
+			},
+			newRequest: newRequest(apiKey.ID.String()),
+		},
+		{
+			name: "NotFound",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(apiKey.ID.String()),
+		},
+		{
+			name: "InvalidID",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest("1"),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	key, apiKey := randomAPIKey(t, user.Username, "accounts:read")
+
+	stubAPIKey := func(store *mockdb.MockStore, apiKey db.ApiKey) {
+		store.EXPECT().
+			GetAPIKeyByHash(gomock.Any(), gomock.Eq(apiKey.KeyHash)).
+			Times(1).
+			Return(apiKey, nil)
+	}
+	newRequest := func(method string, url string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			var body []byte
+			if testCase.request != nil {
+				var err error
+				body, err = json.Marshal(testCase.request)
+				if err != nil {
+					return nil, err
+				}
+			}
+			request := httptest.NewRequest(method, url, bytes.NewReader(body))
+			addBearerToken(request, key)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "ScopeGranted",
+			bulidStubs: func(store *mockdb.MockStore) {
+				stubAPIKey(store, apiKey)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+		{
+			name: "ScopeMissing",
+			request: gin.H{
+				"from_account_id": account.ID,
+				"to_account_id":   account.ID + 1,
+				"amount":          10,
+				"currency":        account.Currency,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				stubAPIKey(store, apiKey)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodPost, "/transfer"),
+		},
+		{
+			name: "SessionOnlyRoute",
+			request: gin.H{
+				"name":   "escalate",
+				"scopes": []string{"transfers:write"},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				stubAPIKey(store, apiKey)
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodPost, "/api_keys"),
+		},
+		{
+			name: "RevokedKey",
+			bulidStubs: func(store *mockdb.MockStore) {
+				revoked := apiKey
+				revoked.IsRevoked = true
+				stubAPIKey(store, revoked)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+		{
+			name: "OtherUsersAccount",
+			bulidStubs: func(store *mockdb.MockStore) {
+				// keys act as depositors, even those of bankers
+				_, otherKey := randomAPIKey(t, util.RandOwner(), "accounts:read")
+				otherKey.KeyHash = apiKey.KeyHash
+				stubAPIKey(store, otherKey)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+		{
+			name: "PasswordChangedSinceCreated",
+			bulidStubs: func(store *mockdb.MockStore) {
+				stubAPIKey(store, apiKey)
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(apiKey.CreatedAt.Add(time.Minute), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+		{
+			name: "UnknownKey",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+		{
+			name: "InternalError",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest(http.MethodGet, fmt.Sprintf("/account/%d", account.ID)),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestRouteScopesMatchRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	routes := make(map[string]bool)
+	for _, route := range server.router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for route := range routeScopes {
+		require.True(t, routes[route], "no route %s", route)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type listAccountEntriesURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountEntriesRequest struct {
+	PageToken string     `form:"page_token"`
+	PageSize  int32      `form:"page_size" binding:"required,min=5,max=10"`
+	StartTime *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   *time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	Direction string     `form:"direction" binding:"omitempty,oneof=debit credit"`
+	MinAmount *int64     `form:"min_amount" binding:"omitempty,min=0"`
+	MaxAmount *int64     `form:"max_amount" binding:"omitempty,min=0"`
+}
+
+type entryResponse struct {
+	ID                    int64     `json:"id"`
+	AccountID             int64     `json:"account_id"`
+	Amount                int64     `json:"amount"`
+	TransferID            *int64    `json:"transfer_id"`
+	CounterpartyAccountID *int64    `json:"counterparty_account_id"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type listAccountEntriesResponse struct {
+	Entries       []entryResponse `json:"entries"`
+	NextPageToken string          `json:"next_page_token"`
+}
+
+func newEntryResponse(row db.ListAccountEntriesRow) entryResponse {
+	return entryResponse{
+		ID:                    row.ID,
+		AccountID:             row.AccountID,
+		Amount:                row.Amount,
+		TransferID:            row.TransferID,
+		CounterpartyAccountID: row.CounterpartyAccountID(),
+		CreatedAt:             row.CreatedAt,
+	}
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri listAccountEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.StartTime != nil && req.EndTime != nil && !req.StartTime.Before(*req.EndTime) {
+		err := errors.New("start_time must be before end_time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		err := errors.New("min_amount must not be greater than max_amount")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scope := pagination.EntriesScope(uri.ID)
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.PageToken)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err := policy.Authorize(authPayload, policy.ViewAccount, account.Owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAccountEntriesParams{
+		AccountID:       account.ID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageLimit:       req.PageSize + 1,
+	}
+	if req.StartTime != nil {
+		arg.StartTime = sql.NullTime{Time: *req.StartTime, Valid: true}
+	}
+	if req.EndTime != nil {
+		arg.EndTime = sql.NullTime{Time: *req.EndTime, Valid: true}
+	}
+	if req.Direction != "" {
+		arg.Direction = sql.NullString{String: req.Direction, Valid: true}
+	}
+	if req.MinAmount != nil {
+		arg.MinAmount = sql.NullInt64{Int64: *req.MinAmount, Valid: true}
+	}
+	if req.MaxAmount != nil {
+		arg.MaxAmount = sql.NullInt64{Int64: *req.MaxAmount, Valid: true}
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	entries, nextPageToken := pagination.NextPage(server.pageTokens, scope, entries, req.PageSize, func(entry db.ListAccountEntriesRow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	})
+
+	rsp := listAccountEntriesResponse{
+		Entries:       make([]entryResponse, 0, len(entries)),
+		NextPageToken: nextPageToken,
+	}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, newEntryResponse(entry))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
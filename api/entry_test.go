@@ -0,0 +1,241 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	otherUser, _ := randomUser(t)
+
+	transferID := util.RandomInt(1, 1000)
+	counterpartyID := util.RandomInt(1001, 2000)
+	entries := []db.ListAccountEntriesRow{
+		{
+			ID:                    1,
+			AccountID:             account.ID,
+			Amount:                -10,
+			TransferID:            &transferID,
+			TransferFromAccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			TransferToAccountID:   sql.NullInt64{Int64: counterpartyID, Valid: true},
+			CreatedAt:             time.Now(),
+		},
+		{
+			ID:        2,
+			AccountID: account.ID,
+			Amount:    25,
+			CreatedAt: time.Now(),
+		},
+	}
+
+	newRequest := func(username string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+			query := url.Values{}
+			for key, value := range testCase.request {
+				if key == "accountID" {
+					continue
+				}
+				query.Set(key, fmt.Sprint(value))
+			}
+			url := fmt.Sprintf("/accounts/%d/entries?%s", testCase.request["accountID"], query.Encode())
+			request, err = http.NewRequest(http.MethodGet, url, nil)
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			return
+		}
+	}
+
+	startTime := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	endTime := time.Now().UTC().Truncate(time.Second)
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"accountID": account.ID,
+				"page_size": 5,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil),
+					store.EXPECT().
+						ListAccountEntries(gomock.Any(), gomock.Eq(db.ListAccountEntriesParams{
+							AccountID: account.ID,
+							PageLimit: 6,
+						})).
+						Times(1).
+						Return(entries, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchEntries(t, recorder, entries)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "OKWithFilters",
+			request: gin.H{
+				"accountID":  account.ID,
+				"page_size":  5,
+				"start_time": startTime.Format(time.RFC3339),
+				"end_time":   endTime.Format(time.RFC3339),
+				"direction":  db.EntryDirectionDebit,
+				"min_amount": 5,
+				"max_amount": 100,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil),
+					store.EXPECT().
+						ListAccountEntries(gomock.Any(), gomock.Eq(db.ListAccountEntriesParams{
+							AccountID: account.ID,
+							StartTime: sql.NullTime{Time: startTime, Valid: true},
+							EndTime:   sql.NullTime{Time: endTime, Valid: true},
+							Direction: sql.NullString{String: db.EntryDirectionDebit, Valid: true},
+							MinAmount: sql.NullInt64{Int64: 5, Valid: true},
+							MaxAmount: sql.NullInt64{Int64: 100, Valid: true},
+							PageLimit: 6,
+						})).
+						Times(1).
+						Return(entries[:1], nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchEntries(t, recorder, entries[:1])
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "UnauthorizedUser",
+			request: gin.H{
+				"accountID": account.ID,
+				"page_size": 5,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(otherUser.Username),
+		},
+		{
+			name: "NotFound",
+			request: gin.H{
+				"accountID": account.ID,
+				"page_size": 5,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "InvalidDirection",
+			request: gin.H{
+				"accountID": account.ID,
+				"page_size": 5,
+				"direction": "sideways",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "InvalidTimeRange",
+			request: gin.H{
+				"accountID":  account.ID,
+				"page_size":  5,
+				"start_time": endTime.Format(time.RFC3339),
+				"end_time":   startTime.Format(time.RFC3339),
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "InvalidAmountRange",
+			request: gin.H{
+				"accountID":  account.ID,
+				"page_size":  5,
+				"min_amount": 100,
+				"max_amount": 5,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"accountID": account.ID,
+				"page_size": 5,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func requireBodyMatchEntries(t *testing.T, recorder *httptest.ResponseRecorder, entries []db.ListAccountEntriesRow) {
+	data, err := ioutil.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	var rsp listAccountEntriesResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	gotEntries := rsp.Entries
+	require.Len(t, gotEntries, len(entries))
+
+	for i, entry := range entries {
+		require.Equal(t, entry.ID, gotEntries[i].ID)
+		require.Equal(t, entry.Amount, gotEntries[i].Amount)
+		require.Equal(t, entry.TransferID, gotEntries[i].TransferID)
+		require.Equal(t, entry.CounterpartyAccountID(), gotEntries[i].CounterpartyAccountID)
+	}
+}
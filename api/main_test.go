@@ -27,13 +27,22 @@ func TestMain(m *testing.M) {
     gin.SetMode(gin.TestMode)
     os.Exit(m.Run())
 }
-func newTestServer(t *testing.T,store db.Store) *Server {
-	config := util.Config{
+func newTestConfig() util.Config {
+	return util.Config{
 		TokenSymmetricKey: util.RandomString(32),
+		PageTokenKey: util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		LoginChallengeDuration: time.Minute,
+		TOTPIssuer: "Simple Bank",
+		LoginBackoffBase: time.Second,
+		LoginLockoutThreshold: 10,
+		LoginIPLockoutThreshold: 50,
+		LoginLockoutDuration: 15 * time.Minute,
 	}
+}
 
-	server,err := NewServer(config,store)
+func newTestServer(t *testing.T,store db.Store) *Server {
+	server,err := NewServer(newTestConfig(),store)
 	require.NoError(t,err)
 
 	return server
@@ -50,6 +59,12 @@ func runTestCases(t *testing.T, testCases []*TestCase) {
 
 			//bulid stubs
 			tc.bulidStubs(store)
+			// none of these tests change passwords, so every token is newer
+			// than the last password change
+			store.EXPECT().
+				GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return(time.Time{}, nil)
 
 			//start test server
 			server := newTestServer(t,store)
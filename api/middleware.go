@@ -1,11 +1,16 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/HzTTT/simple_bank/apikey"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/policy"
 	"github.com/HzTTT/simple_bank/token"
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +21,31 @@ const (
 	authorizationPayloadKey = "authorizatio_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// routeScopes lists the scope that API keys need for each route, keyed by
+// method and path. Routes missing here only accept access tokens.
+var routeScopes = map[string]policy.Scope{
+	"GET /account/:id":                     policy.ScopeAccountsRead,
+	"GET /account":                         policy.ScopeAccountsRead,
+	"GET /accounts/:id/entries":            policy.ScopeAccountsRead,
+	"POST /account":                        policy.ScopeAccountsWrite,
+	"POST /account/:id/freeze":             policy.ScopeAccountsWrite,
+	"POST /account/:id/unfreeze":           policy.ScopeAccountsWrite,
+	"POST /account/:id/close":              policy.ScopeAccountsWrite,
+	"GET /accounts/:id/transfers":          policy.ScopeTransfersRead,
+	"GET /scheduled_transfers":             policy.ScopeTransfersRead,
+	"POST /transfer":                       policy.ScopeTransfersWrite,
+	"POST /transfers/:id/reverse":          policy.ScopeTransfersWrite,
+	"POST /scheduled_transfers":            policy.ScopeTransfersWrite,
+	"POST /scheduled_transfers/:id/pause":  policy.ScopeTransfersWrite,
+	"POST /scheduled_transfers/:id/resume": policy.ScopeTransfersWrite,
+	"POST /scheduled_transfers/:id/cancel": policy.ScopeTransfersWrite,
+}
+
+// authMiddleware verifies the bearer token or API key of a request. Tokens
+// issued before the user last changed their password or to a disabled OAuth
+// client are rejected as well, and so are API keys lacking the scope of the
+// route.
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationKey)
 		if len(authorizationHeader) == 0 {
@@ -40,12 +69,67 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		accessToken := fields[1]
-		payload,err := tokenMaker.VerifyToken(accessToken)
+		var payload *token.Payload
+		var err error
+		if apikey.IsAPIKey(accessToken) {
+			payload, err = apikey.Verify(ctx, store, accessToken)
+			if err != nil && !errors.Is(err, apikey.ErrInvalidKey) {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		} else {
+			payload, err = tokenMaker.VerifyToken(accessToken)
+		}
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized,errorResponse(err))
 			return
 		}
+
+		passwordChangedAt, err := store.GetUserPasswordChangedAt(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if payload.IssuedBefore(passwordChangedAt) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(token.ErrPasswordChanged))
+			return
+		}
+
+		if payload.ClientID != "" {
+			if err := oauth.CheckClient(ctx, store, payload.ClientID); err != nil {
+				if errors.Is(err, oauth.ErrClientDisabled) {
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+					return
+				}
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+
+		scope := routeScopes[ctx.Request.Method+" "+ctx.FullPath()]
+		if err := policy.RequireScope(payload, scope); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
 	 	ctx.Set(authorizationPayloadKey,payload)
 		ctx.Next()
 	}	
 }
+
+// authorizeMiddleware only lets callers through whose role grants action.
+// It has to run after authMiddleware.
+func authorizeMiddleware(action policy.Action) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if err := policy.Authorize(authPayload, action, ""); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -1,14 +1,22 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
 	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,9 +26,10 @@ func addAutgorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenMaker.CreateToken(username, duration)
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -33,12 +42,19 @@ func TestAuthMiddleware(t *testing.T) {
 	taseCases := []struct {
 		name          string
 		setup         func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "Ok",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(time.Time{}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -48,6 +64,11 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "NoAuthorization",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -56,7 +77,12 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnsuppotedAuthorization",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAutgorization(t, request, tokenMaker, "unsupport", "user", time.Minute)
+				addAutgorization(t, request, tokenMaker, "unsupport", "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -65,7 +91,12 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnsuppotedAuthorization",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAutgorization(t, request, tokenMaker, "unsupport", "user", time.Minute)
+				addAutgorization(t, request, tokenMaker, "unsupport", "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -74,7 +105,12 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAutgorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAutgorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -83,20 +119,75 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "TokenIssuedBeforePasswordChange",
+			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(time.Now().Add(time.Second), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(time.Time{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setup: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAutgorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(time.Time{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 	}
 
 	for _, tc := range taseCases {
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
 			server.router.GET(
 				"/auth",
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -113,3 +204,81 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginRightAfterPasswordChangeJWT(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	user, password := randomUser(t)
+	newPassword := util.RandomString(8)
+	var passwordChangedAt time.Time
+
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, username string) (db.User, error) {
+			return user, nil
+		})
+	store.EXPECT().
+		UpdateUserTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+			// like the database, which keeps microseconds
+			passwordChangedAt = time.Now().Truncate(time.Microsecond)
+			user.HashedPassword = arg.HashedPassword.String
+			user.PasswordChangedAt = passwordChangedAt
+			return user, nil
+		})
+	store.EXPECT().
+		GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, username string) (time.Time, error) {
+			return passwordChangedAt, nil
+		})
+	expectLoginAttempt(t, store, nil)
+	store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+	store.EXPECT().GetTOTPCredential(gomock.Any(), gomock.Any()).Times(1).Return(db.TotpCredential{}, sql.ErrNoRows)
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+
+	config := newTestConfig()
+	config.TokenFormat = token.FormatJWT
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+	server.router.GET(
+		"/auth",
+		authMiddleware(server.tokenMaker, server.store),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	body, err := json.Marshal(gin.H{"current_password": password, "new_password": newPassword})
+	require.NoError(t, err)
+	request := httptest.NewRequest(http.MethodPost, "/user/change_password", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// log in within the same second as the change
+	body, err = json.Marshal(gin.H{"username": user.Username, "password": newPassword})
+	require.NoError(t, err)
+	request = httptest.NewRequest(http.MethodPost, "/user/login", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+
+	// the token says it was issued at the start of the second the password
+	// was changed in, which must not count as before the change
+	request = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	request.Header.Set(authorizationKey, fmt.Sprintf("%s %s", authorizationTypeBearer, rsp.AccessToken))
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
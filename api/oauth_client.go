@@ -0,0 +1,129 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type oauthClientResponse struct {
+	ClientID   string    `json:"client_id"`
+	Name       string    `json:"name"`
+	Username   string    `json:"username"`
+	Scopes     []string  `json:"scopes"`
+	IsDisabled bool      `json:"is_disabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newOAuthClientResponse(client db.OauthClient) oauthClientResponse {
+	return oauthClientResponse{
+		ClientID:   client.ClientID,
+		Name:       client.Name,
+		Username:   client.Username,
+		Scopes:     client.Scopes,
+		IsDisabled: client.IsDisabled,
+		CreatedAt:  client.CreatedAt,
+	}
+}
+
+type createOAuthClientRequest struct {
+	Name     string   `json:"name" binding:"required,max=64"`
+	Username string   `json:"username" binding:"required,alphanum"`
+	Scopes   []string `json:"scopes" binding:"required,min=1,dive,scope"`
+}
+
+type createOAuthClientResponse struct {
+	ClientSecret string              `json:"client_secret"`
+	Client       oauthClientResponse `json:"client"`
+}
+
+// createOAuthClient registers the client of a partner service, acting on the
+// accounts of username. The client secret is only ever returned here.
+func (server *Server) createOAuthClient(ctx *gin.Context) {
+	var req createOAuthClientRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	clientID, secret, hash, err := oauth.NewClientCredentials()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	client, err := server.store.CreateOAuthClient(ctx, db.CreateOAuthClientParams{
+		ClientID:   clientID,
+		SecretHash: hash,
+		Name:       req.Name,
+		Username:   req.Username,
+		Scopes:     req.Scopes,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := createOAuthClientResponse{
+		ClientSecret: secret,
+		Client:       newOAuthClientResponse(client),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type listOAuthClientsResponse struct {
+	Clients []oauthClientResponse `json:"clients"`
+}
+
+// listOAuthClients returns every registered client, newest first.
+func (server *Server) listOAuthClients(ctx *gin.Context) {
+	clients, err := server.store.ListOAuthClients(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listOAuthClientsResponse{
+		Clients: make([]oauthClientResponse, len(clients)),
+	}
+	for i, client := range clients {
+		rsp.Clients[i] = newOAuthClientResponse(client)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type disableOAuthClientURI struct {
+	ClientID string `uri:"client_id" binding:"required"`
+}
+
+// disableOAuthClient stops issuing tokens to a client and rejects the
+// tokens it already got.
+func (server *Server) disableOAuthClient(ctx *gin.Context) {
+	var uri disableOAuthClientURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	client, err := server.store.DisableOAuthClient(ctx, uri.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newOAuthClientResponse(client))
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomOAuthClient(t *testing.T, username string) (db.OauthClient, string) {
+	clientID, secret, hash, err := oauth.NewClientCredentials()
+	require.NoError(t, err)
+
+	client := db.OauthClient{
+		ClientID:   clientID,
+		SecretHash: hash,
+		Name:       util.RandomString(6),
+		Username:   username,
+		Scopes:     []string{"accounts:read"},
+		CreatedAt:  time.Now(),
+	}
+	return client, secret
+}
+
+func TestCreateOAuthClientAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	partner, _ := randomUser(t)
+
+	newRequest := func(role string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			body, err := json.Marshal(testCase.request)
+			if err != nil {
+				return nil, err
+			}
+			request := httptest.NewRequest(http.MethodPost, "/oauth_clients", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, role, time.Minute)
+			return request, nil
+		}
+	}
+	request := gin.H{
+		"name":     "partner",
+		"username": partner.Username,
+		"scopes":   []string{"accounts:read", "transfers:write"},
+	}
+
+	testCases := []*TestCase{
+		{
+			name:    "OK",
+			request: request,
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateOAuthClientParams) (db.OauthClient, error) {
+						require.NotEmpty(t, arg.ClientID)
+						require.Equal(t, "partner", arg.Name)
+						require.Equal(t, partner.Username, arg.Username)
+						require.Equal(t, []string{"accounts:read", "transfers:write"}, arg.Scopes)
+						return db.OauthClient{
+							ClientID:   arg.ClientID,
+							SecretHash: arg.SecretHash,
+							Name:       arg.Name,
+							Username:   arg.Username,
+							Scopes:     arg.Scopes,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "secret_hash")
+
+				var rsp createOAuthClientResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.ClientSecret)
+				require.NotEmpty(t, rsp.Client.ClientID)
+				require.Equal(t, partner.Username, rsp.Client.Username)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+		{
+			name:    "Forbidden",
+			request: request,
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(util.BankerRole),
+		},
+		{
+			name: "UnsupportedScope",
+			request: gin.H{
+				"name":     "partner",
+				"username": partner.Username,
+				"scopes":   []string{"users:write"},
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+		{
+			name:    "UserNotFound",
+			request: request,
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OauthClient{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+		{
+			name:    "InternalError",
+			request: request,
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OauthClient{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestListOAuthClientsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	client1, _ := randomOAuthClient(t, util.RandOwner())
+	client2, _ := randomOAuthClient(t, util.RandOwner())
+	clients := []db.OauthClient{client1, client2}
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		request := httptest.NewRequest(http.MethodGet, "/oauth_clients", nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, util.AdminRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListOAuthClients(gomock.Any()).Times(1).Return(clients, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "secret_hash")
+
+				var rsp listOAuthClientsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Clients, len(clients))
+				for i, client := range clients {
+					require.Equal(t, client.ClientID, rsp.Clients[i].ClientID)
+				}
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListOAuthClients(gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestDisableOAuthClientAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	client, _ := randomOAuthClient(t, util.RandOwner())
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		request := httptest.NewRequest(http.MethodPost, "/oauth_clients/"+client.ClientID+"/disable", nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, util.AdminRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				disabled := client
+				disabled.IsDisabled = true
+				store.EXPECT().
+					DisableOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).
+					Times(1).
+					Return(disabled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthClientResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.IsDisabled)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "NotFound",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DisableOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestOAuthTokenAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	client, secret := randomOAuthClient(t, admin.Username)
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).
+					Times(1).
+					Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauth.TokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "Bearer", rsp.TokenType)
+				require.Equal(t, "accounts:read", rsp.Scope)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				form := url.Values{"grant_type": {oauth.GrantTypeClientCredentials}}
+				request := httptest.NewRequest(http.MethodPost, oauth.TokenPath, strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				request.SetBasicAuth(client.ClientID, secret)
+				return request, nil
+			},
+		},
+		{
+			// client tokens carry the depositor role and scopes, so even the
+			// clients of an admin can't manage clients
+			name: "ClientTokenOnAdminRoute",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).
+					Times(1).
+					Return(client, nil)
+				store.EXPECT().ListOAuthClients(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				accessToken, _, err := server.tokenMaker.CreateToken(admin.Username, util.DepositorRole, time.Minute,
+					token.WithScopes(client.Scopes...),
+					token.WithClientID(client.ClientID),
+				)
+				if err != nil {
+					return nil, err
+				}
+				request := httptest.NewRequest(http.MethodGet, "/oauth_clients", nil)
+				addBearerToken(request, accessToken)
+				return request, nil
+			},
+		},
+		{
+			name: "DisabledClientToken",
+			bulidStubs: func(store *mockdb.MockStore) {
+				disabled := client
+				disabled.IsDisabled = true
+				store.EXPECT().
+					GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).
+					Times(1).
+					Return(disabled, nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				accessToken, _, err := server.tokenMaker.CreateToken(admin.Username, util.DepositorRole, time.Minute,
+					token.WithScopes(client.Scopes...),
+					token.WithClientID(client.ClientID),
+				)
+				if err != nil {
+					return nil, err
+				}
+				request := httptest.NewRequest(http.MethodGet, "/account?page_size=5", nil)
+				addBearerToken(request, accessToken)
+				return request, nil
+			},
+		},
+	}
+
+	runTestCases(t, testCases)
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/scheduler"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID   int64      `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID     int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount          int64      `json:"amount" binding:"required,gt=0"`
+	Currency        string     `json:"currency" binding:"required,currency"`
+	CronExpression  string     `json:"cron_expression"`
+	IntervalSeconds int64      `json:"interval_seconds" binding:"min=0"`
+	StartAt         *time.Time `json:"start_at"`
+	EndAt           *time.Time `json:"end_at"`
+	MaxRuns         int32      `json:"max_runs" binding:"min=0"`
+}
+
+type scheduledTransferResponse struct {
+	ID              int64      `json:"id"`
+	Owner           string     `json:"owner"`
+	FromAccountID   int64      `json:"from_account_id"`
+	ToAccountID     int64      `json:"to_account_id"`
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency"`
+	CronExpression  *string    `json:"cron_expression"`
+	IntervalSeconds *int64     `json:"interval_seconds"`
+	StartAt         time.Time  `json:"start_at"`
+	EndAt           *time.Time `json:"end_at"`
+	MaxRuns         *int32     `json:"max_runs"`
+	RunCount        int32      `json:"run_count"`
+	NextRunAt       *time.Time `json:"next_run_at"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func newScheduledTransferResponse(schedule db.ScheduledTransfer) scheduledTransferResponse {
+	rsp := scheduledTransferResponse{
+		ID:            schedule.ID,
+		Owner:         schedule.Owner,
+		FromAccountID: schedule.FromAccountID,
+		ToAccountID:   schedule.ToAccountID,
+		Amount:        schedule.Amount,
+		Currency:      schedule.Currency,
+		StartAt:       schedule.StartAt,
+		RunCount:      schedule.RunCount,
+		Status:        schedule.Status,
+		CreatedAt:     schedule.CreatedAt,
+	}
+	if schedule.CronExpression.Valid {
+		rsp.CronExpression = &schedule.CronExpression.String
+	}
+	if schedule.IntervalSeconds.Valid {
+		rsp.IntervalSeconds = &schedule.IntervalSeconds.Int64
+	}
+	if schedule.EndAt.Valid {
+		rsp.EndAt = &schedule.EndAt.Time
+	}
+	if schedule.MaxRuns.Valid {
+		rsp.MaxRuns = &schedule.MaxRuns.Int32
+	}
+	if schedule.NextRunAt.Valid {
+		rsp.NextRunAt = &schedule.NextRunAt.Time
+	}
+	return rsp
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	startAt := time.Now()
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	}
+
+	rule, err := scheduler.ParseRule(req.CronExpression, time.Duration(req.IntervalSeconds)*time.Second, startAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	firstRun := scheduler.FirstRun(rule, startAt)
+	if req.EndAt != nil && firstRun.After(*req.EndAt) {
+		err := errors.New("schedule ends before its first run")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err := policy.Authorize(authPayload, policy.OperateAccount, fromAccount.Owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.requireVerifiedEmail(ctx, fromAccount.Owner) {
+		return
+	}
+
+	if _, valid := server.findAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		StartAt:       startAt,
+		NextRunAt:     sql.NullTime{Time: firstRun, Valid: true},
+	}
+	if req.CronExpression != "" {
+		arg.CronExpression = sql.NullString{String: req.CronExpression, Valid: true}
+	}
+	if req.IntervalSeconds != 0 {
+		arg.IntervalSeconds = sql.NullInt64{Int64: req.IntervalSeconds, Valid: true}
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+	if req.MaxRuns != 0 {
+		arg.MaxRuns = sql.NullInt32{Int32: req.MaxRuns, Valid: true}
+	}
+
+	schedule, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(schedule))
+}
+
+type listScheduledTransfersRequest struct {
+	PageToken string `form:"page_token"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+type listScheduledTransfersResponse struct {
+	ScheduledTransfers []scheduledTransferResponse `json:"scheduled_transfers"`
+	NextPageToken      string                      `json:"next_page_token"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scope := pagination.ScheduledTransfersScope(authPayload.Username)
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.PageToken)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedules, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:           authPayload.Username,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageLimit:       req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	schedules, nextPageToken := pagination.NextPage(server.pageTokens, scope, schedules, req.PageSize, func(schedule db.ScheduledTransfer) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: schedule.CreatedAt, ID: schedule.ID}
+	})
+
+	rsp := listScheduledTransfersResponse{
+		ScheduledTransfers: make([]scheduledTransferResponse, 0, len(schedules)),
+		NextPageToken:      nextPageToken,
+	}
+	for _, schedule := range schedules {
+		rsp.ScheduledTransfers = append(rsp.ScheduledTransfers, newScheduledTransferResponse(schedule))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateScheduledTransferStatusRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) pauseScheduledTransfer(ctx *gin.Context) {
+	server.updateScheduledTransferStatus(ctx, db.ScheduledTransferStatusPaused)
+}
+
+func (server *Server) resumeScheduledTransfer(ctx *gin.Context) {
+	server.updateScheduledTransferStatus(ctx, db.ScheduledTransferStatusActive)
+}
+
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	server.updateScheduledTransferStatus(ctx, db.ScheduledTransferStatusCancelled)
+}
+
+func (server *Server) updateScheduledTransferStatus(ctx *gin.Context, status string) {
+	var req updateScheduledTransferStatusRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.GetScheduledTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err := policy.Authorize(authPayload, policy.OperateAccount, schedule.Owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateScheduledTransferStatusParams{
+		ID:           schedule.ID,
+		Status:       status,
+		FromStatuses: db.ScheduledTransferSourceStatuses(status),
+	}
+
+	if status == db.ScheduledTransferStatusActive {
+		rule, err := scheduler.RuleOf(schedule)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		nextRunAt, ok := scheduler.ResumeAt(schedule, rule, time.Now())
+		if !ok {
+			err := errors.New("scheduled transfer has no runs left before its end date")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		arg.NextRunAt = sql.NullTime{Time: nextRunAt, Valid: true}
+	}
+
+	schedule, err = server.store.UpdateScheduledTransferStatus(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("cannot move scheduled transfer to %s", status)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(schedule))
+}
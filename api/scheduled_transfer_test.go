@@ -0,0 +1,384 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user1.IsEmailVerified = true
+	account1 := randomAccount(user1.Username)
+	account1.Currency = util.USD
+	user2, _ := randomUser(t)
+	account2 := randomAccount(user2.Username)
+
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	schedule := db.ScheduledTransfer{
+		ID:              util.RandomInt(1, 1000),
+		Owner:           user1.Username,
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          100,
+		Currency:        util.USD,
+		IntervalSeconds: sql.NullInt64{Int64: 3600, Valid: true},
+		StartAt:         startAt,
+		MaxRuns:         sql.NullInt32{Int32: 12, Valid: true},
+		NextRunAt:       sql.NullTime{Time: startAt, Valid: true},
+		Status:          db.ScheduledTransferStatusActive,
+	}
+
+	newRequest := func(username string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+			data, err := json.Marshal(testCase.request)
+			require.NoError(t, err)
+			request, err = http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			return
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           100,
+				"currency":         util.USD,
+				"interval_seconds": 3600,
+				"start_at":         startAt,
+				"max_runs":         12,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil),
+					store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1, nil),
+					store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil),
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Eq(db.CreateScheduledTransferParams{
+							Owner:           user1.Username,
+							FromAccountID:   account1.ID,
+							ToAccountID:     account2.ID,
+							Amount:          100,
+							Currency:        util.USD,
+							IntervalSeconds: sql.NullInt64{Int64: 3600, Valid: true},
+							StartAt:         startAt,
+							MaxRuns:         sql.NullInt32{Int32: 12, Valid: true},
+							NextRunAt:       sql.NullTime{Time: startAt, Valid: true},
+						})).
+						Times(1).
+						Return(schedule, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchScheduledTransfer(t, recorder.Body, schedule)
+			},
+			newRequest: newRequest(user1.Username),
+		},
+		{
+			name: "InvalidRule",
+			request: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           100,
+				"currency":         util.USD,
+				"interval_seconds": 3600,
+				"cron_expression":  "0 8 1 * *",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user1.Username),
+		},
+		{
+			name: "EndsBeforeFirstRun",
+			request: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           100,
+				"currency":         util.USD,
+				"interval_seconds": 3600,
+				"start_at":         startAt,
+				"end_at":           startAt.Add(-time.Minute),
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user1.Username),
+		},
+		{
+			name: "UnauthorizedUser",
+			request: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           100,
+				"currency":         util.USD,
+				"interval_seconds": 3600,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(user2.Username),
+		},
+		{
+			name: "EmailNotVerified",
+			request: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           100,
+				"currency":         util.USD,
+				"interval_seconds": 3600,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				unverified := user1
+				unverified.IsEmailVerified = false
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(unverified, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(user1.Username),
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"cron_expression": "0 8 1 * *",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest(user1.Username),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestListScheduledTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	pageSize := int32(5)
+	schedules := make([]db.ScheduledTransfer, pageSize+1)
+	for i := range schedules {
+		schedules[i] = db.ScheduledTransfer{
+			ID:             int64(i + 1),
+			Owner:          user.Username,
+			Amount:         100,
+			CronExpression: sql.NullString{String: "0 8 1 * *", Valid: true},
+			Status:         db.ScheduledTransferStatusActive,
+		}
+	}
+
+	newRequest := func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+		url := fmt.Sprintf("/scheduled_transfers?page_size=%d", testCase.request["page_size"])
+		request, err = http.NewRequest(http.MethodGet, url, nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Eq(db.ListScheduledTransfersParams{
+						Owner:     user.Username,
+						PageLimit: pageSize + 1,
+					})).
+					Times(1).
+					Return(schedules, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				var rsp listScheduledTransfersResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.ScheduledTransfers, int(pageSize))
+				require.NotEmpty(t, rsp.NextPageToken)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListScheduledTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestUpdateScheduledTransferStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+
+	schedule := db.ScheduledTransfer{
+		ID:              util.RandomInt(1, 1000),
+		Owner:           user.Username,
+		Amount:          100,
+		IntervalSeconds: sql.NullInt64{Int64: 3600, Valid: true},
+		StartAt:         time.Now().Add(-48 * time.Hour),
+		NextRunAt:       sql.NullTime{Time: time.Now().Add(-24 * time.Hour), Valid: true},
+		Status:          db.ScheduledTransferStatusPaused,
+	}
+
+	newRequest := func(action string, username string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (request *http.Request, err error) {
+			url := fmt.Sprintf("/scheduled_transfers/%d/%s", schedule.ID, action)
+			request, err = http.NewRequest(http.MethodPost, url, nil)
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+			return
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "PauseOK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				active := schedule
+				active.Status = db.ScheduledTransferStatusActive
+				paused := schedule
+				gomock.InOrder(
+					store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(active, nil),
+					store.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), gomock.Eq(db.UpdateScheduledTransferStatusParams{
+							ID:           schedule.ID,
+							Status:       db.ScheduledTransferStatusPaused,
+							FromStatuses: []string{db.ScheduledTransferStatusActive},
+						})).
+						Times(1).
+						Return(paused, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest("pause", user.Username),
+		},
+		{
+			name: "ResumeSkipsMissedRuns",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().
+					UpdateScheduledTransferStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateScheduledTransferStatusParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, db.ScheduledTransferStatusActive, arg.Status)
+						require.Equal(t, []string{db.ScheduledTransferStatusPaused}, arg.FromStatuses)
+						require.True(t, arg.NextRunAt.Valid)
+						require.True(t, arg.NextRunAt.Time.After(time.Now()))
+						require.WithinDuration(t, time.Now(), arg.NextRunAt.Time, time.Hour)
+
+						resumed := schedule
+						resumed.Status = arg.Status
+						resumed.NextRunAt = arg.NextRunAt
+						return resumed, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest("resume", user.Username),
+		},
+		{
+			name: "CancelCompleted",
+			bulidStubs: func(store *mockdb.MockStore) {
+				completed := schedule
+				completed.Status = db.ScheduledTransferStatusCompleted
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().UpdateScheduledTransferStatus(gomock.Any(), gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+			newRequest: newRequest("cancel", user.Username),
+		},
+		{
+			name: "UnauthorizedUser",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().UpdateScheduledTransferStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest("cancel", otherUser.Username),
+		},
+		{
+			name: "NotFound",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+				store.EXPECT().UpdateScheduledTransferStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest("pause", user.Username),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func requireBodyMatchScheduledTransfer(t *testing.T, body *bytes.Buffer, schedule db.ScheduledTransfer) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotSchedule scheduledTransferResponse
+	err = json.Unmarshal(data, &gotSchedule)
+	require.NoError(t, err)
+	require.Equal(t, newScheduledTransferResponse(schedule), gotSchedule)
+}
@@ -2,8 +2,14 @@ package api
 
 import (
 	"fmt"
+	"strings"
 
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/fx"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/throttle"
 	"github.com/HzTTT/simple_bank/token"
 	"github.com/HzTTT/simple_bank/util"
 	"github.com/gin-gonic/gin"
@@ -15,25 +21,46 @@ type Server struct {
 	config     util.Config
 	store      db.Store
 	tokenMaker token.Maker
+	fxProvider fx.FXRateProvider
+	pageTokens *pagination.Signer
+	loginGuard *throttle.Guard
+	issuer     *oauth.Issuer
 	router     *gin.Engine
 }
 
 // NewServer creates a new HTTP server and configures routing.
 // It also sets up the database.
 func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMakerFromConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	pageTokens, err := pagination.NewSigner(config.PageTokenKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create page token signer: %w", err)
+	}
 	server := &Server{
 		store:      store,
 		config:     config,
 		tokenMaker: tokenMaker,
+		fxProvider: fx.NewDBRateProvider(store),
+		pageTokens: pageTokens,
+		loginGuard: throttle.NewGuard(store, config),
+		issuer:     oauth.NewIssuer(store, tokenMaker, config.AccessTokenDuration),
 		router:     gin.Default(),
 	}
 
+	// login failures are counted per client IP, so only take it from
+	// X-Forwarded-For when the request came through a configured proxy
+	err = server.router.SetTrustedProxies(trustedProxies(config.TrustedProxies))
+	if err != nil {
+		return nil, fmt.Errorf("cannot set trusted proxies: %w", err)
+	}
+
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("role", validRole)
+		v.RegisterValidation("scope", validScope)
 	}
 
 	server.setupRouter()
@@ -41,18 +68,64 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	return server, nil
 }
 
+// trustedProxies drops the blank entries an empty TRUSTED_PROXIES leaves, so
+// that none are trusted by default.
+func trustedProxies(proxies []string) []string {
+	var trusted []string
+	for _, proxy := range proxies {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted = append(trusted, proxy)
+		}
+	}
+	return trusted
+}
+
 func (server *Server) setupRouter() {
 	server.router.POST("/user", server.createUser)
 	server.router.POST("/user/login", server.loginUser)
+	server.router.POST("/user/login/totp", server.loginUserTOTP)
 	server.router.POST("/tokens/renew_access",server.renewAccessToken)
+	server.router.GET("/verify_email", server.verifyEmail)
+	server.router.POST("/user/request_password_reset", server.requestPasswordReset)
+	server.router.POST("/user/reset_password", server.resetPassword)
+	server.router.POST("/user/logout", server.logout)
+	server.router.POST(oauth.TokenPath, gin.WrapH(server.issuer))
 
-	authRoutes := server.router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := server.router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+
+	authRoutes.PATCH("/users/:username", server.updateUser)
+	authRoutes.PATCH("/users/:username/role", authorizeMiddleware(policy.ManageRoles), server.updateUserRole)
+	authRoutes.POST("/user/change_password", server.changePassword)
+	authRoutes.POST("/user/logout_all_devices", server.logoutAllDevices)
+	authRoutes.POST("/user/totp/enroll", server.enrollTOTP)
+	authRoutes.POST("/user/totp/confirm", server.confirmTOTP)
+	authRoutes.GET("/sessions", server.listSessions)
+	authRoutes.POST("/sessions/:id/revoke", server.revokeSession)
+	authRoutes.POST("/api_keys", server.createAPIKey)
+	authRoutes.GET("/api_keys", server.listAPIKeys)
+	authRoutes.POST("/api_keys/:id/revoke", server.revokeAPIKey)
+	authRoutes.POST("/oauth_clients", authorizeMiddleware(policy.ManageOAuthClients), server.createOAuthClient)
+	authRoutes.GET("/oauth_clients", authorizeMiddleware(policy.ManageOAuthClients), server.listOAuthClients)
+	authRoutes.POST("/oauth_clients/:client_id/disable", authorizeMiddleware(policy.ManageOAuthClients), server.disableOAuthClient)
 
 	authRoutes.POST("/account", server.createAccount)
 	authRoutes.GET("/account/:id", server.getAccount)
 	authRoutes.GET("/account", server.listAccount)
+	authRoutes.POST("/account/:id/freeze", server.freezeAccount)
+	authRoutes.POST("/account/:id/unfreeze", server.unfreezeAccount)
+	authRoutes.POST("/account/:id/close", server.closeAccount)
+	authRoutes.PATCH("/account/:id/overdraft_limit", authorizeMiddleware(policy.SetOverdraftLimit), server.updateOverdraftLimit)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/transfers", server.listAccountTransfers)
 	
 	authRoutes.POST("/transfer", server.Transfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+
+	authRoutes.POST("/scheduled_transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers", server.listScheduledTransfers)
+	authRoutes.POST("/scheduled_transfers/:id/pause", server.pauseScheduledTransfer)
+	authRoutes.POST("/scheduled_transfers/:id/resume", server.resumeScheduledTransfer)
+	authRoutes.POST("/scheduled_transfers/:id/cancel", server.cancelScheduledTransfer)
 }
 
 func (server *Server) Start(address string) error {
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomSession(username string) db.Session {
+	return db.Session{
+		ID:        uuid.New(),
+		Username:  username,
+		UserAgent: "test",
+		ClientIp:  "127.0.0.1",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestListSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	sessions := []db.Session{randomSession(user.Username), randomSession(user.Username)}
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		request := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ListActiveSessionsParams) ([]db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now(), arg.Now, time.Second)
+						return sessions, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "refresh_token")
+
+				var rsp listSessionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Sessions, len(sessions))
+				for i, session := range sessions {
+					require.Equal(t, session.ID, rsp.Sessions[i].ID)
+					require.Equal(t, session.UserAgent, rsp.Sessions[i].UserAgent)
+					require.Equal(t, session.ClientIp, rsp.Sessions[i].ClientIP)
+				}
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InternalError",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestRevokeSessionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	session := randomSession(user.Username)
+
+	newRequest := func(id string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			request := httptest.NewRequest(http.MethodPost, "/sessions/"+id+"/revoke", nil)
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Eq(session.ID)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+			newRequest: newRequest(session.ID.String()),
+		},
+		{
+			name: "OtherUsersSession",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(randomSession(otherUser.Username), nil)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(session.ID.String()),
+		},
+		{
+			name: "NotFound",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(session.ID.String()),
+		},
+		{
+			name: "InvalidID",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest("not-a-uuid"),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestLogoutAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newRequest, sessionOf := refreshTokenRequest(t, http.MethodPost, "/user/logout", user.Username)
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				var sessionID uuid.UUID
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						sessionID = id
+						return sessionOf(id), nil
+					})
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						require.Equal(t, sessionID, id)
+						return db.Session{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "RevokedSession",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						session := sessionOf(id)
+						session.IsBlocked = true
+						return session, nil
+					})
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestLogoutAllDevicesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				request := httptest.NewRequest(http.MethodPost, "/user/logout_all_devices", nil)
+				addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+				return request, nil
+			},
+		},
+		{
+			name: "NoAuthorization",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				return httptest.NewRequest(http.MethodPost, "/user/logout_all_devices", nil), nil
+			},
+		},
+	}
+
+	runTestCases(t, testCases)
+}
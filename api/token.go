@@ -1,71 +1,96 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 	"time"
 
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/refresh"
 	"github.com/gin-gonic/gin"
 )
 
 type renewAccessTokenRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// renewAccessToken trades a refresh token for a new access token and a new
+// refresh token. The old refresh token is retired and can't be used again.
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	session, err := refresh.Verify(ctx, server.store, server.tokenMaker, req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		refreshError(ctx, err)
+		return
 	}
 
-	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	// the role may have changed since the session was created
+	user, err := server.store.GetUser(ctx, session.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	if session.IsBlocked  {
-		err := errors.New("blocked session")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
-	}
-
-	if session.Username != refreshPayload.Username {
-		err := errors.New("incorrect session user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	assessToken, accseePayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	if session.RefreshToken != req.RefreshToken {
-		err := errors.New("mismatched session token")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	assessToken, accseePayload, err := server.tokenMaker.CreateToken(refreshPayload.Username, server.config.AccessTokenDuration)
+	_, err = server.store.RenewSessionTx(ctx, db.RenewSessionTxParams{
+		ParentID:     session.ID,
+		ID:           refreshPayload.ID,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
 	if err != nil {
+		if errors.Is(err, db.ErrSessionRetired) {
+			refreshError(ctx, refresh.BlockReused(ctx, server.store, session))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	rsp := renewAccessTokenResponse{
 		AccessToken:           assessToken,
 		AccessTokenExpiresAt:  accseePayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+// refreshError answers a request whose refresh token was turned down by
+// refresh.Verify or refresh.BlockReused.
+func refreshError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, refresh.ErrSessionNotFound):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, refresh.ErrInvalidToken):
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
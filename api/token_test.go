@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshTokenRequest builds requests that carry a refresh token made by the
+// test server. The token only exists once the server does, so stubs read it
+// back through the returned session func when they are called.
+func refreshTokenRequest(t *testing.T, method string, url string, username string) (
+	newRequest func(testCase *TestCase, server *Server) (*http.Request, error),
+	sessionOf func(id uuid.UUID) db.Session,
+) {
+	var refreshToken string
+	familyID := uuid.New()
+
+	newRequest = func(testCase *TestCase, server *Server) (*http.Request, error) {
+		token, _, err := server.tokenMaker.CreateToken(username, util.DepositorRole, time.Hour)
+		require.NoError(t, err)
+		refreshToken = token
+
+		request := gin.H{"refresh_token": refreshToken}
+		for key, value := range testCase.request {
+			request[key] = value
+		}
+		body, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}
+
+	sessionOf = func(id uuid.UUID) db.Session {
+		return db.Session{
+			ID:           id,
+			Username:     username,
+			RefreshToken: refreshToken,
+			ExpiresAt:    time.Now().Add(time.Hour),
+			FamilyID:     familyID,
+		}
+	}
+
+	return newRequest, sessionOf
+}
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newRequest, sessionOf := refreshTokenRequest(t, http.MethodPost, "/tokens/renew_access", user.Username)
+	var parentID uuid.UUID
+	var newRefreshToken string
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						parentID = id
+						return sessionOf(id), nil
+					})
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RenewSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RenewSessionTxParams) (db.Session, error) {
+						require.Equal(t, parentID, arg.ParentID)
+						require.NotEqual(t, parentID, arg.ID)
+						require.NotEmpty(t, arg.RefreshToken)
+						newRefreshToken = arg.RefreshToken
+						return db.Session{ID: arg.ID, RefreshToken: arg.RefreshToken}, nil
+					})
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.AccessToken)
+				require.Equal(t, newRefreshToken, rsp.RefreshToken)
+				require.False(t, rsp.RefreshTokenExpiresAt.IsZero())
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ReusedToken",
+			bulidStubs: func(store *mockdb.MockStore) {
+				var familyID uuid.UUID
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						session := sessionOf(id)
+						session.IsRetired = true
+						familyID = session.FamilyID
+						return session, nil
+					})
+				store.EXPECT().RenewSessionTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) error {
+						require.Equal(t, familyID, id)
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ConcurrentRenewal",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						return sessionOf(id), nil
+					})
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RenewSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrSessionRetired)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "RevokedSession",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						session := sessionOf(id)
+						session.IsBlocked = true
+						return session, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ExpiredSession",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						session := sessionOf(id)
+						session.ExpiresAt = time.Now().Add(-time.Minute)
+						return session, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "MismatchedToken",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+						session := sessionOf(id)
+						session.RefreshToken = "other-token"
+						return session, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "SessionNotFound",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidToken",
+			request: gin.H{
+				"refresh_token": "invalid-token",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 
 	mockdb "github.com/HzTTT/simple_bank/db/mock"
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -19,11 +21,20 @@ import (
 
 func TestTransferAPI(t *testing.T) {
 	user1, _ := randomUser(t)
+	user1.IsEmailVerified = true
 	account1 := randomAccount(user1.Username)
 	user2, _ := randomUser(t)
 	account2 := randomAccount(user2.Username)
 	account1.Currency = "USD"
 	account2.Currency = "USD"
+	account3 := randomAccount(user2.Username)
+	account3.Currency = "EUR"
+	fxRate := db.FxRate{
+		BaseCurrency: "USD",
+		QuoteCurrency: "EUR",
+		Rate: "0.9",
+		UpdatedAt: time.Now(),
+	}
 	amount := int64(10)
 	toEntry := db.Entry{
 		ID: 1,
@@ -52,7 +63,13 @@ func TestTransferAPI(t *testing.T) {
 		data, err := json.Marshal(testCase.request)
 		require.NoError(t, err)
 		request, err = http.NewRequest(http.MethodPost,url,bytes.NewReader(data)) 
-		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user1.Username,time.Minute)
+		addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,user1.Username,util.DepositorRole,time.Minute)
+		return
+	}
+
+	newIdempotentRequest := func(testCase *TestCase,server *Server) (request *http.Request, err error) {
+		request, err = newRequest(testCase,server)
+		request.Header.Set(idempotencyKeyHeader,"key")
 		return
 	}
 
@@ -73,6 +90,7 @@ func TestTransferAPI(t *testing.T) {
 				}
 				gomock.InOrder(
 					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
 					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil),
 					store.EXPECT().TransferTx(gomock.Any(),gomock.Eq(arg)).Times(1).Return(transferResult,nil),
 				)
@@ -111,6 +129,7 @@ func TestTransferAPI(t *testing.T) {
 			bulidStubs: func(store *mockdb.MockStore) {
 				gomock.InOrder(
 					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
 					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(db.Account{},sql.ErrNoRows),
 				)
 			},
@@ -135,12 +154,436 @@ func TestTransferAPI(t *testing.T) {
 			},
 			newRequest: newRequest,
 		},
+		{
+			name: "CrossCurrencyOK",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account3.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID: account3.ID,
+					Amount: amount,
+					ToAmount: amount * 9 / 10,
+					ExchangeRate: fxRate.Rate,
+					RateTimestamp: fxRate.UpdatedAt,
+				}
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account3.ID)).Times(1).Return(account3,nil),
+					store.EXPECT().GetFXRate(gomock.Any(),gomock.Eq(db.GetFXRateParams{BaseCurrency: "USD", QuoteCurrency: "EUR"})).Times(1).Return(fxRate,nil),
+					store.EXPECT().TransferTx(gomock.Any(),gomock.Eq(arg)).Times(1).Return(transferResult,nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "NoExchangeRate",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account3.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account3.ID)).Times(1).Return(account3,nil),
+				)
+				store.EXPECT().GetFXRate(gomock.Any(),gomock.Any()).Times(2).Return(db.FxRate{},sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnprocessableEntity,recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InsufficientFunds",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil),
+					store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.TransferTxResult{},db.ErrInsufficientFunds),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnprocessableEntity,recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "IdempotentOK",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				arg := db.IdempotentTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID: account2.ID,
+						Amount: amount,
+					},
+					Currency: "USD",
+					Username: user1.Username,
+					IdempotencyKey: "key",
+				}
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil),
+					store.EXPECT().IdempotentTransferTx(gomock.Any(),gomock.Eq(arg)).Times(1).Return(transferResult,nil),
+				)
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+				requireBodyMatchTransferResult(t,transferResult,recorder.Body)
+			},
+			newRequest: newIdempotentRequest,
+		},
+		{
+			name: "IdempotencyKeyReused",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil),
+					store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(user1,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil),
+					store.EXPECT().IdempotentTransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.TransferTxResult{},db.ErrIdempotencyKeyReused),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusConflict,recorder.Code)
+			},
+			newRequest: newIdempotentRequest,
+		},
+		{
+			name: "EmailNotVerified",
+			request: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": "USD",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				unverified := user1
+				unverified.IsEmailVerified = false
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
+				store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user1.Username)).Times(1).Return(unverified,nil)
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusForbidden,recorder.Code)
+			},
+			newRequest: newRequest,
+		},
 	}
 
 	runTestCases(t,testCases)
 	
 }
 
+func TestListAccountTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	otherUser, _ := randomUser(t)
+
+	pageSize := int32(5)
+	transfers := make([]db.Transfer, pageSize+1)
+	for i := range transfers {
+		transfers[i] = db.Transfer{
+			ID: int64(i + 1),
+			FromAccountID: account.ID,
+			ToAccountID: util.RandomInt(1001, 2000),
+			Amount: util.RandMoney(),
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+	}
+
+	newRequest := func(username string) func(testCase *TestCase,server *Server) (*http.Request, error) {
+		return func(testCase *TestCase,server *Server) (request *http.Request, err error) {
+			url := fmt.Sprintf("/accounts/%d/transfers?page_size=%d", account.ID, testCase.request["page_size"])
+			if pageToken, ok := testCase.request["page_token"]; ok {
+				url += fmt.Sprintf("&page_token=%s", pageToken)
+			}
+			request, err = http.NewRequest(http.MethodGet, url, nil)
+			addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,username,util.DepositorRole,time.Minute)
+			return
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil),
+					store.EXPECT().ListTransfers(gomock.Any(),gomock.Eq(db.ListTransfersParams{
+						AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+						PageLimit: pageSize + 1,
+					})).Times(1).Return(transfers,nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t,err)
+				var rsp listAccountTransfersResponse
+				err = json.Unmarshal(data,&rsp)
+				require.NoError(t,err)
+				require.Len(t,rsp.Transfers,int(pageSize))
+				require.Equal(t,transfers[:pageSize],rsp.Transfers)
+				require.NotEmpty(t,rsp.NextPageToken)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "InvalidPageToken",
+			request: gin.H{
+				"page_size": pageSize,
+				"page_token": "invalid",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Any()).Times(0)
+				store.EXPECT().ListTransfers(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusBadRequest,recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "UnauthorizedUser",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
+				store.EXPECT().ListTransfers(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnauthorized,recorder.Code)
+			},
+			newRequest: newRequest(otherUser.Username),
+		},
+		{
+			name: "InternalError",
+			request: gin.H{
+				"page_size": pageSize,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
+				store.EXPECT().ListTransfers(gomock.Any(),gomock.Any()).Times(1).Return(nil,sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusInternalServerError,recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+	}
+
+	runTestCases(t,testCases)
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	user2, _ := randomUser(t)
+	account2 := randomAccount(user2.Username)
+	banker, _ := randomUser(t)
+
+	transfer := db.Transfer{
+		ID: util.RandomInt(1, 1000),
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 100,
+		ToAmount: 100,
+		ExchangeRate: "1",
+	}
+	reversal := db.Transfer{
+		ID: transfer.ID + 1,
+		FromAccountID: account2.ID,
+		ToAccountID: account1.ID,
+		Amount: 40,
+		ToAmount: 40,
+		ExchangeRate: "1",
+		ReversalOfID: &transfer.ID,
+	}
+	result := db.ReverseTransferTxResult{
+		TransferTxResult: db.TransferTxResult{
+			Transfer: reversal,
+			FromAccount: account2,
+			ToAccount: account1,
+		},
+		OriginalTransfer: transfer,
+		RefundedAmount: 40,
+	}
+
+	newRequest := func(username string, role string) func(testCase *TestCase,server *Server) (*http.Request, error) {
+		return func(testCase *TestCase,server *Server) (request *http.Request, err error) {
+			url := fmt.Sprintf("/transfers/%d/reverse", transfer.ID)
+			data, err := json.Marshal(testCase.request)
+			require.NoError(t, err)
+			request, err = http.NewRequest(http.MethodPost,url,bytes.NewReader(data))
+			addAutgorization(t,request,server.tokenMaker,authorizationTypeBearer,username,role,time.Minute)
+			return
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"amount": 40,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil),
+					store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil),
+					store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Eq(db.ReverseTransferTxParams{
+						TransferID: transfer.ID,
+						Amount: 40,
+					})).Times(1).Return(result,nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t,err)
+				var gotResult db.ReverseTransferTxResult
+				err = json.Unmarshal(data,&gotResult)
+				require.NoError(t,err)
+				require.Equal(t,result,gotResult)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+		{
+			name: "FullRefundByBanker",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Eq(db.ReverseTransferTxParams{
+					TransferID: transfer.ID,
+				})).Times(1).Return(result,nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+			},
+			newRequest: newRequest(banker.Username, util.BankerRole),
+		},
+		{
+			name: "SenderCannotReverse",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnauthorized,recorder.Code)
+			},
+			newRequest: newRequest(user1.Username, util.DepositorRole),
+		},
+		{
+			name: "NegativeAmount",
+			request: gin.H{
+				"amount": -1,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Any()).Times(0)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusBadRequest,recorder.Code)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+		{
+			name: "TransferNotFound",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{},sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusNotFound,recorder.Code)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+		{
+			name: "RefundExceedsTransfer",
+			request: gin.H{
+				"amount": 200,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{},db.ErrRefundExceedsTransfer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnprocessableEntity,recorder.Code)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+		{
+			name: "TransferIsReversal",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{},db.ErrTransferIsReversal)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusConflict,recorder.Code)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+		{
+			name: "InternalError",
+			request: gin.H{},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(),gomock.Eq(transfer.ID)).Times(1).Return(transfer,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{},sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusInternalServerError,recorder.Code)
+			},
+			newRequest: newRequest(user2.Username, util.DepositorRole),
+		},
+	}
+
+	runTestCases(t,testCases)
+}
+
 func requireBodyMatchTransferResult(t *testing.T, transferResult db.TransferTxResult, body *bytes.Buffer) {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t,err)
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// enrollTOTP starts setting up two-factor authentication with a new secret.
+// It only takes effect once confirmTOTP gets a code generated from it.
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	secret, err := util.RandomTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.CreateTOTPCredential(ctx, db.CreateTOTPCredentialParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := enrollTOTPResponse{
+		Secret: secret,
+		URI:    util.TOTPURI(server.config.TOTPIssuer, authPayload.Username, secret),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTOTP enables two-factor authentication and hands out the recovery
+// codes, which can't be shown again.
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username: authPayload.Username,
+		Code:     req.Code,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTOTPNotEnrolled):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrTOTPAlreadyEnabled):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrTwoFactorCodeInvalid):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{RecoveryCodes: result.RecoveryCodes})
+}
+
+type loginChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"two_factor_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"`
+}
+
+// createLoginChallenge answers a login of a user with two-factor
+// authentication, who passed the password check.
+func (server *Server) createLoginChallenge(ctx *gin.Context, user db.User) {
+	challengeToken, err := util.RandomSecret(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	challenge, err := server.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(challengeToken),
+		ExpiredAt: time.Now().Add(server.config.LoginChallengeDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := loginChallengeResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: challenge.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type loginUserTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+// loginUserTOTP completes a login challenge with a TOTP or recovery code.
+func (server *Server) loginUserTOTP(ctx *gin.Context) {
+	var req loginUserTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.CompleteLoginChallengeTx(ctx, db.CompleteLoginChallengeTxParams{
+		Token:        req.ChallengeToken,
+		Code:         req.Code,
+		RecoveryCode: req.RecoveryCode,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrLoginChallengeInvalid),
+			errors.Is(err, db.ErrLoginChallengeUsed),
+			errors.Is(err, db.ErrLoginChallengeExpired),
+			errors.Is(err, db.ErrLoginChallengeLocked),
+			errors.Is(err, db.ErrTwoFactorCodeInvalid):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	rsp, valid := server.createLoginSession(ctx, user)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
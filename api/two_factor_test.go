@@ -0,0 +1,274 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		request := httptest.NewRequest(http.MethodPost, "/user/totp/enroll", nil)
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTOTPCredential(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateTOTPCredentialParams) (db.TotpCredential, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.TotpCredential{Username: arg.Username, Secret: arg.Secret}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.Secret)
+
+				uri, err := url.Parse(rsp.URI)
+				require.NoError(t, err)
+				require.Equal(t, "otpauth", uri.Scheme)
+				require.Equal(t, rsp.Secret, uri.Query().Get("secret"))
+				require.Contains(t, uri.Path, user.Username)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "AlreadyEnabled",
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTOTPCredential(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	recoveryCodes := []string{util.RandomString(10), util.RandomString(10)}
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/user/totp/confirm", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"code": "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Eq(db.EnableTOTPTxParams{
+						Username: user.Username,
+						Code:     "123456",
+					})).
+					Times(1).
+					Return(db.EnableTOTPTxResult{RecoveryCodes: recoveryCodes}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, recoveryCodes, rsp.RecoveryCodes)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "WrongCode",
+			request: gin.H{
+				"code": "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EnableTOTPTxResult{}, db.ErrTwoFactorCodeInvalid)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "NotEnrolled",
+			request: gin.H{
+				"code": "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EnableTOTPTxResult{}, db.ErrTOTPNotEnrolled)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidCode",
+			request: gin.H{
+				"code": "12ab",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestLoginUserTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	challengeToken := util.RandomString(32)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/user/login/totp", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"challenge_token": challengeToken,
+				"code":            "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteLoginChallengeTx(gomock.Any(), gomock.Eq(db.CompleteLoginChallengeTxParams{
+						Token: challengeToken,
+						Code:  "123456",
+					})).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, user, recorder.Body)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "RecoveryCode",
+			request: gin.H{
+				"challenge_token": challengeToken,
+				"recovery_code":   "recovery",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteLoginChallengeTx(gomock.Any(), gomock.Eq(db.CompleteLoginChallengeTxParams{
+						Token:        challengeToken,
+						RecoveryCode: "recovery",
+					})).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "WrongCode",
+			request: gin.H{
+				"challenge_token": challengeToken,
+				"code":            "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrTwoFactorCodeInvalid)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ExpiredChallenge",
+			request: gin.H{
+				"challenge_token": challengeToken,
+				"code":            "123456",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrLoginChallengeExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "MissingCode",
+			request: gin.H{
+				"challenge_token": challengeToken,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
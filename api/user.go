@@ -2,11 +2,17 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/throttle"
+	"github.com/HzTTT/simple_bank/token"
 	"github.com/HzTTT/simple_bank/util"
+	"github.com/HzTTT/simple_bank/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -16,6 +22,8 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -32,6 +40,8 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -48,14 +58,22 @@ func (server *Server) createUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		AfterCreate: func(q db.Querier, user db.User) error {
+			_, err := worker.TaskSendWelcomeEmail.Enqueue(ctx, q, worker.SendWelcomeEmailPayload{
+				Username: user.Username,
+			})
+			return err
+		},
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -87,38 +105,85 @@ type loginUserResponse struct {
 	User                  userResponse `json:"user"`
 }
 
+// loginUser checks the password of a user. Users with two-factor
+// authentication get a login challenge to complete with loginUserTOTP instead
+// of tokens. Failed logins delay, and eventually lock out, further attempts
+// on the same username or from the same client IP.
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	var user db.User
+	err := server.loginGuard.Attempt(ctx, req.Username, ctx.ClientIP(), func() error {
+		var err error
+		user, err = server.store.GetUser(ctx, req.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return throttle.Failed(err)
+			}
+			return err
+		}
+
+		err = util.CheckPassword(req.Password, user.HashedPassword)
+		if err != nil {
+			return throttle.Failed(err)
+		}
+		return nil
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		var throttled *throttle.Error
+		switch {
+		case errors.As(err, &throttled):
+			ctx.Header("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		case errors.Is(err, sql.ErrNoRows):
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+		case errors.Is(err, throttle.ErrLoginFailed):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		}
+		return
+	}
+
+	credential, err := server.store.GetTOTPCredential(ctx, user.Username)
+	if err != nil && err != sql.ErrNoRows {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if err == nil && credential.IsEnabled {
+		server.createLoginChallenge(ctx, user)
+		return
+	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	rsp, valid := server.createLoginSession(ctx, user)
+	if !valid {
+		return
 	}
 
-	assessToken, accseePayload, err := server.tokenMaker.CreateToken(req.Username, server.config.AccessTokenDuration)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// createLoginSession issues the access and refresh tokens of a user who
+// completed logging in.
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) (loginUserResponse, bool) {
+	assessToken, accseePayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return loginUserResponse{}, false
 	}
 
 	refreshToken, refreshpayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return loginUserResponse{}, false
 	}
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
@@ -129,10 +194,11 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshpayload.ExpiredAt,
+		FamilyID:     refreshpayload.ID,
 	})
-
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return loginUserResponse{}, false
 	}
 
 	rsp := loginUserResponse{
@@ -143,6 +209,284 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		RefreshTokenExpiresAt: refreshpayload.ExpiredAt,
 		User:                  newUserResponse(user),
 	}
+	return rsp, true
+}
+
+type verifyEmailRequest struct {
+	EmailID    int64  `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required"`
+}
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-	ctx.JSON(http.StatusOK, rsp)
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:    req.EmailID,
+		SecretCode: req.SecretCode,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrVerifyEmailInvalid):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrVerifyEmailUsed),
+			errors.Is(err, db.ErrVerifyEmailExpired):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verifyEmailResponse{IsVerified: result.User.IsEmailVerified})
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// requestPasswordReset mails a reset link to the owner of a verified email
+// address. It answers the same whether or not the address is registered or
+// verified, so it cannot be used to find out who banks with us.
+func (server *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.Status(http.StatusAccepted)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.IsEmailVerified {
+		ctx.Status(http.StatusAccepted)
+		return
+	}
+
+	_, err = worker.TaskSendPasswordResetEmail.Enqueue(ctx, server.store, worker.SendPasswordResetEmailPayload{
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		Token:          req.Token,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrPasswordResetTokenInvalid):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrPasswordResetTokenUsed),
+			errors.Is(err, db.ErrPasswordResetTokenExpired):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type updateUserURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	// a new email address has to be verified again
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Password *string `json:"password" binding:"omitempty,min=6"`
+	// required when users change their own password or email
+	CurrentPassword string `json:"current_password"`
+}
+
+// updateUser changes the fields set in the request. Admins may update any
+// user. Changing your own password or email needs the current password. A
+// new password signs the user out everywhere, including the token used for
+// this call.
+func (server *Server) updateUser(ctx *gin.Context) {
+	var uri updateUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err := policy.Authorize(authPayload, policy.UpdateUser, uri.Username); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	user, valid := server.updateUserTx(ctx, uri.Username, req)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	_, valid := server.updateUserTx(ctx, authPayload.Username, updateUserRequest{
+		Password:        &req.NewPassword,
+		CurrentPassword: req.CurrentPassword,
+	})
+	if !valid {
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (server *Server) updateUserTx(ctx *gin.Context, username string, req updateUserRequest) (db.User, bool) {
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return user, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, false
+	}
+
+	arg := db.UpdateUserTxParams{
+		Username: user.Username,
+	}
+
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if (req.Password != nil || emailChanged) && authPayload.Username == user.Username {
+		if err := util.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+			err := errors.New("incorrect current password")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return user, false
+		}
+	}
+
+	if req.Password != nil {
+		hashedPassword, err := util.HashPassword(*req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return user, false
+		}
+		arg.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+	}
+
+	if emailChanged {
+		oldEmail := user.Email
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+		arg.AfterUpdate = func(q db.Querier, user db.User) error {
+			return worker.EnqueueEmailChange(ctx, q, user.Username, oldEmail)
+		}
+	}
+
+	user, err = server.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return user, false
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, false
+	}
+
+	return user, true
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+// updateUserRole assigns a role to a user. The new role is carried by the
+// tokens issued from the next login or renewal on.
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri updateUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -10,11 +11,15 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	mockdb "github.com/HzTTT/simple_bank/db/mock"
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/throttle"
 	"github.com/HzTTT/simple_bank/util"
+	"github.com/HzTTT/simple_bank/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/lib/pq"
@@ -28,11 +33,12 @@ type eqMatcherUserArg struct {
 }
 
 func (e eqMatcherUserArg) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+	txArg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
 
+	arg := txArg.CreateUserParams
 	err := util.CheckPassword(e.password, arg.HashedPassword)
 	if err != nil {
 		return false
@@ -79,9 +85,19 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:    user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqUserArg(arg, password)).
+					CreateUserTx(gomock.Any(), EqUserArg(arg, password)).
 					Times(1).
-					Return(user, nil)
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.User, error) {
+						return user, arg.AfterCreate(store, user)
+					})
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+						require.Equal(t, worker.TaskSendWelcomeEmail.Kind, arg.Kind)
+						require.JSONEq(t, fmt.Sprintf(`{"username":%q}`, user.Username), string(arg.Payload))
+						return db.Job{ID: 1, Kind: arg.Kind}, nil
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -89,6 +105,31 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			newRequest: newRequest,
 		},
+		{
+			name: "EnqueueJobError",
+			request: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.User, error) {
+						return db.User{}, arg.AfterCreate(store, user)
+					})
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
 		{
 			name: "InternalError",
 			request: gin.H{
@@ -99,7 +140,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
 			},
@@ -118,7 +159,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, &pq.Error{Code: "23505"})
 			},
@@ -137,7 +178,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -155,7 +196,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -173,7 +214,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -185,6 +226,20 @@ func TestCreateUserAPI(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+// expectLoginAttempt has the throttle of a login find failures.
+func expectLoginAttempt(t *testing.T, store *mockdb.MockStore, failures []db.LoginFailure) {
+	store.EXPECT().
+		GetLoginFailures(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.GetLoginFailuresParams) ([]db.LoginFailure, error) {
+			require.Equal(t, clientIP, arg.ClientIp)
+			return failures, nil
+		})
+}
+
+// clientIP is the address httptest requests come from.
+const clientIP = "192.0.2.1"
+
 func TestLoginUserAPI(t *testing.T) {
 	user, password := randomUser(t)
 
@@ -205,11 +260,18 @@ func TestLoginUserAPI(t *testing.T) {
 				"password":  password,
 			},
 			bulidStubs: func(store *mockdb.MockStore) {
-
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().
+					ClearLoginFailures(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
 				store.EXPECT().
 					GetUser(gomock.Any(),gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					GetTOTPCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
 				store.EXPECT().
 					CreateSession(gomock.Any(),gomock.Any()).
 					Times(1)
@@ -220,11 +282,942 @@ func TestLoginUserAPI(t *testing.T) {
 			},
 			newRequest: newRequest,
 		},
+		{
+			name: "WrongPassword",
+			request: gin.H{
+				"username": user.Username,
+				"password": "wrong-password",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureParams) ([]db.LoginFailure, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, clientIP, arg.ClientIp)
+						return []db.LoginFailure{{Scope: throttle.ScopeUsername, Key: arg.Username, Failures: 1}}, nil
+					})
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetTOTPCredential(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "TwoFactorRequired",
+			request: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().
+					ClearLoginFailures(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetTOTPCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{Username: user.Username, IsEnabled: true}, nil)
+				store.EXPECT().
+					CreateLoginChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.TokenHash)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiredAt, time.Second)
+						return db.LoginChallenge{Username: arg.Username, TokenHash: arg.TokenHash, ExpiredAt: arg.ExpiredAt}, nil
+					})
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+
+				var rsp loginChallengeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.TwoFactorRequired)
+				require.NotEmpty(t, rsp.ChallengeToken)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "PendingTwoFactor",
+			request: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().
+					ClearLoginFailures(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetTOTPCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{Username: user.Username}, nil)
+				store.EXPECT().CreateLoginChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, user, recorder.Body)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "UserNotFound",
+			request: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "Delayed",
+			request: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, []db.LoginFailure{{
+					Scope:        throttle.ScopeUsername,
+					Key:          user.Username,
+					Failures:     throttle.FreeFailures,
+					LastFailedAt: time.Now(),
+				}})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+				require.NotContains(t, recorder.Body.String(), "locked")
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "LockedOut",
+			request: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, []db.LoginFailure{{
+					Scope:        throttle.ScopeIP,
+					Key:          clientIP,
+					Failures:     50,
+					LastFailedAt: time.Now(),
+				}})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "900", recorder.Header().Get("Retry-After"))
+				require.Contains(t, recorder.Body.String(), "locked")
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ForwardedForNotTrusted",
+			request: gin.H{
+				"username": user.Username,
+				"password": "wrong-password",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				expectLoginAttempt(t, store, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureParams) ([]db.LoginFailure, error) {
+						require.Equal(t, clientIP, arg.ClientIp)
+						return []db.LoginFailure{{Scope: throttle.ScopeIP, Key: arg.ClientIp, Failures: 1}}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				request, err := newRequest(testCase, server)
+				if err != nil {
+					return nil, err
+				}
+				request.Header.Set("X-Forwarded-For", "203.0.113.7")
+				return request, nil
+			},
+		},
 	}
 
 	runTestCases(t,testCases)
 }
 
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+	emailID := util.RandomInt(1, 1000)
+	secretCode := util.RandomString(32)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		query := url.Values{}
+		for key, value := range testCase.request {
+			query.Set(key, fmt.Sprint(value))
+		}
+		return httptest.NewRequest(http.MethodGet, "/verify_email?"+query.Encode(), nil), nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"email_id":    emailID,
+				"secret_code": secretCode,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(db.VerifyEmailTxParams{EmailID: emailID, SecretCode: secretCode})).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"is_verified":true}`, recorder.Body.String())
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "MissingSecretCode",
+			request: gin.H{
+				"email_id": emailID,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidCode",
+			request: gin.H{
+				"email_id":    emailID,
+				"secret_code": secretCode,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailInvalid)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "UsedCode",
+			request: gin.H{
+				"email_id":    emailID,
+				"secret_code": secretCode,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailUsed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ExpiredCode",
+			request: gin.H{
+				"email_id":    emailID,
+				"secret_code": secretCode,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/user/request_password_reset", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"email": user.Email,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+						require.Equal(t, worker.TaskSendPasswordResetEmail.Kind, arg.Kind)
+						require.JSONEq(t, fmt.Sprintf(`{"username":%q}`, user.Username), string(arg.Payload))
+						return db.Job{ID: 1, Kind: arg.Kind}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "UnknownEmail",
+			request: gin.H{
+				"email": user.Email,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "UnverifiedEmail",
+			request: gin.H{
+				"email": user.Email,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				unverified := user
+				unverified.IsEmailVerified = false
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidEmail",
+			request: gin.H{
+				"email": "invalid-email",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	token := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/user/reset_password", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ResetPasswordTxParams) (db.User, error) {
+						require.Equal(t, token, arg.Token)
+						require.NoError(t, util.CheckPassword(newPassword, arg.HashedPassword))
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "PasswordTooShort",
+			request: gin.H{
+				"token":        token,
+				"new_password": "123",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "InvalidToken",
+			request: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrPasswordResetTokenInvalid)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "ExpiredToken",
+			request: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrPasswordResetTokenExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestUpdateUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	otherUser, _ := randomUser(t)
+	newFullName := util.RandOwner()
+	newEmail := util.RandomEmail()
+	newPassword := util.RandomString(8)
+
+	newRequest := func(username string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			body, err := json.Marshal(testCase.request)
+			if err != nil {
+				return nil, err
+			}
+			request := httptest.NewRequest(http.MethodPatch, "/users/"+username, bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "FullName",
+			request: gin.H{
+				"full_name": newFullName,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, sql.NullString{String: newFullName, Valid: true}, arg.FullName)
+						require.False(t, arg.Email.Valid)
+						require.False(t, arg.HashedPassword.Valid)
+						require.Nil(t, arg.AfterUpdate)
+
+						updatedUser := user
+						updatedUser.FullName = newFullName
+						return updatedUser, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				updatedUser := user
+				updatedUser.FullName = newFullName
+				requireBodyMatchUser(t, updatedUser, recorder.Body)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "Email",
+			request: gin.H{
+				"email":            newEmail,
+				"current_password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.Equal(t, sql.NullString{String: newEmail, Valid: true}, arg.Email)
+
+						updatedUser := user
+						updatedUser.Email = newEmail
+						return updatedUser, arg.AfterUpdate(store, updatedUser)
+					})
+				gomock.InOrder(
+					store.EXPECT().
+						CreateJob(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+							require.Equal(t, worker.TaskSendVerifyEmail.Kind, arg.Kind)
+							return db.Job{ID: 1, Kind: arg.Kind}, nil
+						}),
+					store.EXPECT().
+						CreateJob(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+							require.Equal(t, worker.TaskSendEmailChangedEmail.Kind, arg.Kind)
+							require.JSONEq(t, fmt.Sprintf(`{"username":%q,"old_email":%q}`, user.Username, user.Email), string(arg.Payload))
+							return db.Job{ID: 2, Kind: arg.Kind}, nil
+						}),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "EmailWithoutCurrentPassword",
+			request: gin.H{
+				"email": newEmail,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "EmailWrongCurrentPassword",
+			request: gin.H{
+				"email":            newEmail,
+				"current_password": "wrong-password",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "AdminChangesOtherUsersEmail",
+			request: gin.H{
+				"email": newEmail,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(otherUser.Username)).Times(1).Return(otherUser, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.Equal(t, sql.NullString{String: newEmail, Valid: true}, arg.Email)
+
+						updatedUser := otherUser
+						updatedUser.Email = newEmail
+						return updatedUser, arg.AfterUpdate(store, updatedUser)
+					})
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(2).Return(db.Job{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				body, err := json.Marshal(testCase.request)
+				if err != nil {
+					return nil, err
+				}
+				request := httptest.NewRequest(http.MethodPatch, "/users/"+otherUser.Username, bytes.NewReader(body))
+				request.Header.Set("Content-Type", "application/json")
+				addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.AdminRole, time.Minute)
+				return request, nil
+			},
+		},
+		{
+			name: "SameEmail",
+			request: gin.H{
+				"email": user.Email,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.False(t, arg.Email.Valid)
+						require.Nil(t, arg.AfterUpdate)
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "Password",
+			request: gin.H{
+				"password":         newPassword,
+				"current_password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.True(t, arg.HashedPassword.Valid)
+						require.NoError(t, util.CheckPassword(newPassword, arg.HashedPassword.String))
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "WrongCurrentPassword",
+			request: gin.H{
+				"password":         newPassword,
+				"current_password": "wrong-password",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "OtherUser",
+			request: gin.H{
+				"full_name": newFullName,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest(otherUser.Username),
+		},
+		{
+			name: "AdminUpdatesOtherUser",
+			request: gin.H{
+				"password": newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(otherUser.Username)).Times(1).Return(otherUser, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.Equal(t, otherUser.Username, arg.Username)
+						require.True(t, arg.HashedPassword.Valid)
+						require.NoError(t, util.CheckPassword(newPassword, arg.HashedPassword.String))
+						return otherUser, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, otherUser, recorder.Body)
+			},
+			newRequest: func(testCase *TestCase, server *Server) (*http.Request, error) {
+				body, err := json.Marshal(testCase.request)
+				if err != nil {
+					return nil, err
+				}
+				request := httptest.NewRequest(http.MethodPatch, "/users/"+otherUser.Username, bytes.NewReader(body))
+				request.Header.Set("Content-Type", "application/json")
+				addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.AdminRole, time.Minute)
+				return request, nil
+			},
+		},
+		{
+			name: "InvalidEmail",
+			request: gin.H{
+				"email": "invalid-email",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+		{
+			name: "DuplicateEmail",
+			request: gin.H{
+				"email":            newEmail,
+				"current_password": password,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(user.Username),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestChangePasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+	newPassword := util.RandomString(8)
+
+	newRequest := func(testCase *TestCase, server *Server) (*http.Request, error) {
+		body, err := json.Marshal(testCase.request)
+		if err != nil {
+			return nil, err
+		}
+		request := httptest.NewRequest(http.MethodPost, "/user/change_password", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		return request, nil
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"current_password": password,
+				"new_password":     newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.CheckPassword(newPassword, arg.HashedPassword.String))
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "WrongCurrentPassword",
+			request: gin.H{
+				"current_password": "wrong-password",
+				"new_password":     newPassword,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+		{
+			name: "PasswordTooShort",
+			request: gin.H{
+				"current_password": password,
+				"new_password":     "123",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest,
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
+func TestUpdateUserRoleAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	admin, _ := randomUser(t)
+
+	newRequest := func(role string) func(testCase *TestCase, server *Server) (*http.Request, error) {
+		return func(testCase *TestCase, server *Server) (*http.Request, error) {
+			body, err := json.Marshal(testCase.request)
+			if err != nil {
+				return nil, err
+			}
+			request := httptest.NewRequest(http.MethodPatch, "/users/"+user.Username+"/role", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			addAutgorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, role, time.Minute)
+			return request, nil
+		}
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "OK",
+			request: gin.H{
+				"role": util.BankerRole,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				updatedUser := user
+				updatedUser.Role = util.BankerRole
+				store.EXPECT().
+					UpdateUserRole(gomock.Any(), gomock.Eq(db.UpdateUserRoleParams{
+						Username: user.Username,
+						Role:     util.BankerRole,
+					})).
+					Times(1).
+					Return(updatedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				updatedUser := user
+				updatedUser.Role = util.BankerRole
+				requireBodyMatchUser(t, updatedUser, recorder.Body)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+		{
+			name: "NotAdmin",
+			request: gin.H{
+				"role": util.AdminRole,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			newRequest: newRequest(util.BankerRole),
+		},
+		{
+			name: "UnsupportedRole",
+			request: gin.H{
+				"role": "superuser",
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+		{
+			name: "UserNotFound",
+			request: gin.H{
+				"role": util.BankerRole,
+			},
+			bulidStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRole(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+			newRequest: newRequest(util.AdminRole),
+		},
+	}
+
+	runTestCases(t, testCases)
+}
+
 func requireBodyMatchUser(t *testing.T, user db.User, body *bytes.Buffer) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
@@ -235,6 +1228,7 @@ func requireBodyMatchUser(t *testing.T, user db.User, body *bytes.Buffer) {
 	require.Equal(t, user.Username, gotUser.Username)
 	require.Equal(t, user.FullName, gotUser.FullName)
 	require.Equal(t, user.Email, gotUser.Email)
+	require.Equal(t, user.Role, gotUser.Role)
 }
 
 func randomUser(t *testing.T) (user db.User, password string) {
@@ -246,6 +1240,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		FullName:       util.RandOwner(),
 		Email:          util.RandomEmail(),
 		HashedPassword: hashedPassword,
+		Role:           util.DepositorRole,
 	}
 	return user, password
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"github.com/HzTTT/simple_bank/policy"
 	"github.com/HzTTT/simple_bank/util"
 	"github.com/go-playground/validator/v10"
 )
@@ -10,4 +11,18 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
         return util.IsSupportedCurrency(currency)
     }
     return false
-}
\ No newline at end of file
+}
+
+var validRole validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if role, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedRole(role)
+	}
+	return false
+}
+
+var validScope validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if scope, ok := fieldLevel.Field().Interface().(string); ok {
+		return policy.IsSupportedScope(scope)
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	key, hash, err := Generate()
+	require.NoError(t, err)
+	require.True(t, IsAPIKey(key))
+	require.Equal(t, util.HashSecret(key), hash)
+
+	other, _, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	require.False(t, IsAPIKey("v2.local.token"))
+}
+
+func randomAPIKey(hash string) db.ApiKey {
+	return db.ApiKey{
+		ID:        uuid.New(),
+		Username:  util.RandOwner(),
+		Name:      util.RandomString(6),
+		KeyHash:   hash,
+		Scopes:    []string{"accounts:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, hash, err := Generate()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore, apiKey db.ApiKey)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(apiKey, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidKey)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				apiKey.IsRevoked = true
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(apiKey, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidKey)
+				require.ErrorContains(t, err, ErrRevokedKey.Error())
+			},
+		},
+		{
+			name: "Expired",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				apiKey.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(apiKey, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidKey)
+				require.ErrorContains(t, err, ErrExpiredKey.Error())
+			},
+		},
+		{
+			name: "NoScopes",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				apiKey.Scopes = nil
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(apiKey, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidKey)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash)).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.True(t, errors.Is(err, sql.ErrConnDone))
+				require.False(t, errors.Is(err, ErrInvalidKey))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			apiKey := randomAPIKey(hash)
+			tc.buildStubs(store, apiKey)
+
+			payload, err := Verify(context.Background(), store, key)
+			tc.checkError(t, err)
+			if err != nil {
+				require.Nil(t, payload)
+				return
+			}
+			require.Equal(t, apiKey.ID, payload.ID)
+			require.Equal(t, apiKey.Username, payload.Username)
+			require.Equal(t, util.DepositorRole, payload.Role)
+			require.Equal(t, apiKey.Scopes, payload.Scopes)
+			require.Equal(t, apiKey.ExpiresAt, payload.ExpiredAt)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "rate_timestamp";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
+DROP TABLE IF EXISTS "fx_rates";
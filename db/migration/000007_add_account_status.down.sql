@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "accounts_owner_currency_idx";
+CREATE UNIQUE INDEX "accounts_owner_currency_idx" ON "accounts" ("owner", "currency");
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_status_valid";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of_id" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversal_of_id");
+
+COMMENT ON COLUMN "transfers"."reversal_of_id" IS 'transfer that this one refunds, null for ordinary transfers';
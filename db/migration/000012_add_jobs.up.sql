@@ -0,0 +1,19 @@
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "jobs_max_attempts_check" CHECK ("max_attempts" > 0),
+  CONSTRAINT "jobs_status_check" CHECK ("status" IN ('pending', 'running', 'succeeded', 'dead'))
+);
+
+CREATE INDEX ON "jobs" ("status", "run_at");
+
+COMMENT ON COLUMN "jobs"."locked_until" IS 'lease of the worker running the job, after which another worker may take it over';
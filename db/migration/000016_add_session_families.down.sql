@@ -0,0 +1,3 @@
+ALTER TABLE "sessions" DROP COLUMN "is_retired";
+ALTER TABLE "sessions" DROP COLUMN "parent_id";
+ALTER TABLE "sessions" DROP COLUMN "family_id";
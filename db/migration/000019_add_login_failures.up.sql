@@ -0,0 +1,11 @@
+CREATE TABLE "login_failures" (
+  "scope" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "failures" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("scope", "key")
+);
+
+COMMENT ON COLUMN "login_failures"."scope" IS 'what key identifies, either a username or a client ip';
+
+COMMENT ON COLUMN "login_failures"."failures" IS 'failed logins in a row, counted again from 1 once the last one is old enough';
@@ -0,0 +1,19 @@
+CREATE TABLE "oauth_clients" (
+  "client_id" varchar PRIMARY KEY,
+  "secret_hash" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "is_disabled" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "oauth_clients" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "oauth_clients" ("username");
+
+COMMENT ON COLUMN "oauth_clients"."secret_hash" IS 'sha256 of the client secret, the secret itself is only shown once';
+
+COMMENT ON COLUMN "oauth_clients"."username" IS 'the user whose accounts the client acts on';
+
+COMMENT ON COLUMN "oauth_clients"."scopes" IS 'the most a token of the client may be granted, never empty';
@@ -21,10 +21,11 @@ FOR NO KEY UPDATE;
 -- name: ListAccounts :many
 SELECT *
 FROM accounts
-WHERE owner = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3; 
+WHERE owner = sqlc.arg(owner)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -42,3 +43,14 @@ RETURNING *;
 DELETE FROM accounts
 WHERE id = $1;
 
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -1,9 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id
 )VALUES(
-    $1,$2
+    $1,$2,$3
 )RETURNING *;
 
 -- name: GetEntry :one
@@ -14,6 +15,31 @@ WHERE id = $1 LIMIT 1;
 -- name: ListEntries :many
 SELECT *
 FROM entries
-ORDER BY id
-LIMIT $1
-OFFSET $2;
\ No newline at end of file
+WHERE sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListAccountEntries :many
+SELECT
+    entries.id,
+    entries.account_id,
+    entries.amount,
+    entries.created_at,
+    entries.transfer_id,
+    transfers.from_account_id AS transfer_from_account_id,
+    transfers.to_account_id AS transfer_to_account_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = sqlc.arg(account_id)
+    AND (sqlc.narg(start_time)::timestamptz IS NULL OR entries.created_at >= sqlc.narg(start_time))
+    AND (sqlc.narg(end_time)::timestamptz IS NULL OR entries.created_at < sqlc.narg(end_time))
+    AND (sqlc.narg(direction)::varchar IS NULL
+        OR (sqlc.narg(direction) = 'debit' AND entries.amount < 0)
+        OR (sqlc.narg(direction) = 'credit' AND entries.amount > 0))
+    AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(entries.amount) >= sqlc.narg(min_amount))
+    AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(entries.amount) <= sqlc.narg(max_amount))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR (entries.created_at, entries.id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::bigint))
+ORDER BY entries.created_at, entries.id
+LIMIT sqlc.arg(page_limit);
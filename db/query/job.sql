@@ -0,0 +1,76 @@
+-- name: CreateJob :one
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetJob :one
+SELECT *
+FROM jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimJobs :many
+-- Running jobs whose lease has expired belong to a worker that died,
+-- so they are claimed again like pending ones.
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until)::timestamptz,
+    updated_at = now()
+WHERE id IN (
+    SELECT id
+    FROM jobs
+    WHERE (status = 'pending' AND run_at <= sqlc.arg(now)::timestamptz)
+        OR (status = 'running' AND locked_until <= sqlc.arg(now)::timestamptz)
+    ORDER BY run_at, id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :one
+-- Only the worker holding the lease of this attempt may settle the job,
+-- see also RetryJob and KillJob.
+UPDATE jobs
+SET
+    status = 'succeeded',
+    locked_until = NULL,
+    last_error = NULL,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+    AND status = 'running'
+    AND attempts = sqlc.arg(attempts)
+    AND locked_until = sqlc.arg(locked_until)
+RETURNING *;
+
+-- name: RetryJob :one
+UPDATE jobs
+SET
+    status = 'pending',
+    run_at = sqlc.arg(run_at),
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error)::varchar,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+    AND status = 'running'
+    AND attempts = sqlc.arg(attempts)
+    AND locked_until = sqlc.arg(locked_until)
+RETURNING *;
+
+-- name: KillJob :one
+UPDATE jobs
+SET
+    status = 'dead',
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error)::varchar,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+    AND status = 'running'
+    AND attempts = sqlc.arg(attempts)
+    AND locked_until = sqlc.arg(locked_until)
+RETURNING *;
@@ -0,0 +1,25 @@
+-- name: GetLoginFailures :many
+SELECT * FROM login_failures
+WHERE (scope = 'username' AND key = sqlc.arg(username))
+    OR (scope = 'ip' AND key = sqlc.arg(client_ip));
+
+-- name: RecordLoginFailure :many
+INSERT INTO login_failures (
+    scope,
+    key,
+    failures,
+    last_failed_at
+) VALUES
+    ('username', sqlc.arg(username), 1, now()),
+    ('ip', sqlc.arg(client_ip), 1, now())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+        WHEN login_failures.last_failed_at < sqlc.arg(reset_before) THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE scope = 'username' AND key = $1;
@@ -0,0 +1,26 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    client_id,
+    secret_hash,
+    name,
+    username,
+    scopes
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE client_id = $1
+LIMIT 1;
+
+-- name: ListOAuthClients :many
+SELECT * FROM oauth_clients
+ORDER BY created_at DESC;
+
+-- name: DisableOAuthClient :one
+UPDATE oauth_clients
+SET is_disabled = TRUE
+WHERE client_id = $1
+RETURNING *;
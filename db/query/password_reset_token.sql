@@ -0,0 +1,22 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (
+    username,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetPasswordResetTokenForUpdate :one
+SELECT *
+FROM password_reset_tokens
+WHERE token_hash = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: UseUserPasswordResetTokens :exec
+-- A reset uses up every token the user still holds, not only the one
+-- that was redeemed.
+UPDATE password_reset_tokens
+SET is_used = TRUE
+WHERE username = $1
+    AND is_used = FALSE;
@@ -6,11 +6,47 @@ INSERT INTO sessions (
         user_agent,
         client_ip,
         is_blocked,
-        expires_at
+        expires_at,
+        family_id,
+        parent_id
     )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: ListActiveSessions :many
+SELECT * FROM sessions
+WHERE username = sqlc.arg(username)
+    AND is_blocked = FALSE
+    AND is_retired = FALSE
+    AND expires_at > sqlc.arg(now)::timestamptz
+ORDER BY created_at DESC;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE id = $1
+RETURNING *;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1
+    AND is_blocked = FALSE;
+
+-- name: RetireSession :one
+UPDATE sessions
+SET is_retired = TRUE
+WHERE id = $1
+    AND is_retired = FALSE
+    AND is_blocked = FALSE
+RETURNING *;
+
+-- name: BlockSessionFamily :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE family_id = $1
+    AND is_blocked = FALSE;
@@ -2,9 +2,13 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    rate_timestamp,
+    reversal_of_id
 ) VALUES (
-    $1,$2,$3
+    $1,$2,$3,$4,$5,$6,$7
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -12,9 +16,24 @@ SELECT *
 FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT *
+FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetTransferReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount
+FROM transfers
+WHERE reversal_of_id = sqlc.arg(transfer_id)::bigint;
+
 -- name: ListTransfers :many
 SELECT *
 FROM transfers
-ORDER BY id
-LIMIT $1
-OFFSET $2;
\ No newline at end of file
+WHERE (sqlc.narg(account_id)::bigint IS NULL
+        OR from_account_id = sqlc.narg(account_id)
+        OR to_account_id = sqlc.narg(account_id))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,81 @@
+-- name: CreateTOTPCredential :one
+-- Starting over replaces a pending secret, but never an enabled one.
+INSERT INTO totp_credentials (
+    username,
+    secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE totp_credentials.is_enabled = FALSE
+RETURNING *;
+
+-- name: GetTOTPCredential :one
+SELECT * FROM totp_credentials
+WHERE username = $1 LIMIT 1;
+
+-- name: GetTOTPCredentialForUpdate :one
+SELECT * FROM totp_credentials
+WHERE username = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: EnableTOTPCredential :one
+UPDATE totp_credentials
+SET is_enabled = TRUE,
+    last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UseTOTPStep :execrows
+UPDATE totp_credentials
+SET last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+    AND last_used_step < sqlc.arg(step);
+
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    username,
+    code_hash
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET is_used = TRUE
+WHERE username = $1
+    AND code_hash = $2
+    AND is_used = FALSE
+RETURNING *;
+
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    username,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetLoginChallengeForUpdate :one
+SELECT * FROM login_challenges
+WHERE token_hash = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: AddLoginChallengeAttempt :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UseLoginChallenge :exec
+UPDATE login_challenges
+SET is_used = TRUE
+WHERE id = $1;
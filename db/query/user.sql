@@ -10,4 +10,52 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = TRUE
+WHERE username = sqlc.arg(username)
+    AND email = sqlc.arg(email)
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = sqlc.arg(hashed_password),
+    password_changed_at = now()
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUser :one
+-- A new email address has to be verified again, a new password moves
+-- password_changed_at forward.
+UPDATE users
+SET
+    full_name = COALESCE(sqlc.narg(full_name), full_name),
+    is_email_verified = CASE
+        WHEN sqlc.narg(email)::varchar IS NULL OR sqlc.narg(email)::varchar = email THEN is_email_verified
+        ELSE FALSE
+    END,
+    email = COALESCE(sqlc.narg(email), email),
+    hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
+    password_changed_at = CASE
+        WHEN sqlc.narg(hashed_password)::varchar IS NULL THEN password_changed_at
+        ELSE now()
+    END
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE username = sqlc.arg(username)
+RETURNING *;
@@ -32,6 +32,21 @@ func CreateAccount(t *testing.T) Account {
 	return account
 }
 
+// createAccountWithBalance creates an account that can fund transfers of
+// the given size without hitting its overdraft limit.
+func createAccountWithBalance(t *testing.T, balance int64) Account {
+	account := CreateAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+
+	return account
+}
+
 func TestCreateAccount(t *testing.T) {
 	CreateAccount(t)
 }
@@ -79,24 +94,33 @@ func TestDelectAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
-	var lastAccount Account
-	for i := 0; i < 10; i++ {
-		lastAccount = CreateAccount(t)
+	owner := createRandomUser(t)
+	var created []Account
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    owner.Username,
+			Balance:  util.RandMoney(),
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		created = append(created, account)
 	}
 
 	arg := ListAccountsParams{
-		Limit: 5, 
-		Offset: 0,
-		Owner: lastAccount.Owner,
+		Owner:     owner.Username,
+		PageLimit: 2,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
-
 	require.NoError(t, err)
-	require.NotEmpty(t, accounts)
-	require.NotEmpty(t,accounts)
+	require.Equal(t, created[:2], accounts)
 
-	for _, account := range accounts {
-		require.Equal(t,lastAccount,account)
-	}
+	// resume after the last account of the first page
+	last := accounts[len(accounts)-1]
+	arg.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+	arg.CursorID = sql.NullInt64{Int64: last.ID, Valid: true}
+
+	accounts, err = testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, created[2:], accounts)
 }
@@ -0,0 +1,21 @@
+package db
+
+// Entry directions as seen from the account that owns the entry.
+const (
+	EntryDirectionDebit  = "debit"
+	EntryDirectionCredit = "credit"
+)
+
+// CounterpartyAccountID returns the other account of the transfer that
+// produced the entry, or nil when the entry is not linked to a transfer.
+func (row ListAccountEntriesRow) CounterpartyAccountID() *int64 {
+	if !row.TransferFromAccountID.Valid || !row.TransferToAccountID.Valid {
+		return nil
+	}
+
+	counterparty := row.TransferFromAccountID.Int64
+	if counterparty == row.AccountID {
+		counterparty = row.TransferToAccountID.Int64
+	}
+	return &counterparty
+}
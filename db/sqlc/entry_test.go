@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -53,17 +54,114 @@ func TestListEntries(t *testing.T) {
 	}
 
 	arg := ListEntriesParams{
-		Limit:  5,
-		Offset: 5,
+		PageLimit: 5,
 	}
 
 	entries, err := testQueries.ListEntries(context.Background(), arg)
 
 	require.NoError(t, err)
-	require.NotEmpty(t, entries)
 	require.Len(t, entries, 5)
 
-	for _, entry := range entries {
+	last := entries[len(entries)-1]
+	arg.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+	arg.CursorID = sql.NullInt64{Int64: last.ID, Valid: true}
+
+	nextEntries, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, nextEntries, 5)
+
+	for _, entry := range nextEntries {
 		require.NotEmpty(t, entry)
+		require.False(t, entry.CreatedAt.Before(last.CreatedAt))
+		require.NotEqual(t, last.ID, entry.ID)
 	}
 }
+
+func TestListAccountEntries(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	outgoing, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	incoming, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+
+	deposit, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+		AccountID: account1.ID,
+		Amount:    200,
+	})
+	require.NoError(t, err)
+
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	require.Equal(t, outgoing.FromEntry.ID, entries[0].ID)
+	require.Equal(t, outgoing.Transfer.ID, *entries[0].TransferID)
+	require.Equal(t, account2.ID, *entries[0].CounterpartyAccountID())
+
+	require.Equal(t, incoming.ToEntry.ID, entries[1].ID)
+	require.Equal(t, incoming.Transfer.ID, *entries[1].TransferID)
+	require.Equal(t, account2.ID, *entries[1].CounterpartyAccountID())
+
+	require.Equal(t, deposit.ID, entries[2].ID)
+	require.Nil(t, entries[2].TransferID)
+	require.Nil(t, entries[2].CounterpartyAccountID())
+
+	debits, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		Direction: sql.NullString{String: EntryDirectionDebit, Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, debits, 1)
+	require.Equal(t, outgoing.FromEntry.ID, debits[0].ID)
+
+	credits, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		Direction: sql.NullString{String: EntryDirectionCredit, Valid: true},
+		MinAmount: sql.NullInt64{Int64: 100, Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, credits, 1)
+	require.Equal(t, deposit.ID, credits[0].ID)
+
+	future, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		StartTime: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, future)
+
+	firstPage, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		PageLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
+
+	secondPage, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:       account1.ID,
+		CursorCreatedAt: sql.NullTime{Time: firstPage[1].CreatedAt, Valid: true},
+		CursorID:        sql.NullInt64{Int64: firstPage[1].ID, Valid: true},
+		PageLimit:       2,
+	})
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	require.Equal(t, deposit.ID, secondPage[0].ID)
+}
@@ -0,0 +1,11 @@
+package db
+
+// Job statuses. A job is pending until a worker claims it, running while a
+// worker holds its lease, and ends succeeded or, once it has used up its
+// attempts, dead.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
@@ -0,0 +1,276 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: job.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimJobs = `-- name: ClaimJobs :many
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_until = $1::timestamptz,
+    updated_at = now()
+WHERE id IN (
+    SELECT id
+    FROM jobs
+    WHERE (status = 'pending' AND run_at <= $2::timestamptz)
+        OR (status = 'running' AND locked_until <= $2::timestamptz)
+    ORDER BY run_at, id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type ClaimJobsParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Now         time.Time `json:"now"`
+	BatchSize   int32     `json:"batch_size"`
+}
+
+// Running jobs whose lease has expired belong to a worker that died,
+// so they are claimed again like pending ones.
+func (q *Queries) ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, claimJobs, arg.LockedUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Job{}
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeJob = `-- name: CompleteJob :one
+UPDATE jobs
+SET
+    status = 'succeeded',
+    locked_until = NULL,
+    last_error = NULL,
+    updated_at = now()
+WHERE id = $1
+    AND status = 'running'
+    AND attempts = $2
+    AND locked_until = $3
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type CompleteJobParams struct {
+	ID          int64        `json:"id"`
+	Attempts    int32        `json:"attempts"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+// Only the worker holding the lease of this attempt may settle the job,
+// see also RetryJob and KillJob.
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, completeJob, arg.ID, arg.Attempts, arg.LockedUntil)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createJob = `-- name: CreateJob :one
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type CreateJobParams struct {
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+}
+
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, createJob,
+		arg.Kind,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getJob = `-- name: GetJob :one
+SELECT id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+FROM jobs
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJob(ctx context.Context, id int64) (Job, error) {
+	row := q.db.QueryRowContext(ctx, getJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const killJob = `-- name: KillJob :one
+UPDATE jobs
+SET
+    status = 'dead',
+    locked_until = NULL,
+    last_error = $1::varchar,
+    updated_at = now()
+WHERE id = $2
+    AND status = 'running'
+    AND attempts = $3
+    AND locked_until = $4
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type KillJobParams struct {
+	LastError   string       `json:"last_error"`
+	ID          int64        `json:"id"`
+	Attempts    int32        `json:"attempts"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+func (q *Queries) KillJob(ctx context.Context, arg KillJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, killJob,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+		arg.LockedUntil,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const retryJob = `-- name: RetryJob :one
+UPDATE jobs
+SET
+    status = 'pending',
+    run_at = $1,
+    locked_until = NULL,
+    last_error = $2::varchar,
+    updated_at = now()
+WHERE id = $3
+    AND status = 'running'
+    AND attempts = $4
+    AND locked_until = $5
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type RetryJobParams struct {
+	RunAt       time.Time    `json:"run_at"`
+	LastError   string       `json:"last_error"`
+	ID          int64        `json:"id"`
+	Attempts    int32        `json:"attempts"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, retryJob,
+		arg.RunAt,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+		arg.LockedUntil,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomJob(t *testing.T, runAt time.Time) Job {
+	arg := CreateJobParams{
+		Kind:        "test",
+		Payload:     []byte(`{}`),
+		MaxAttempts: 3,
+		RunAt:       runAt,
+	}
+
+	job, err := testQueries.CreateJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Kind, job.Kind)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Zero(t, job.Attempts)
+	require.False(t, job.LockedUntil.Valid)
+
+	return job
+}
+
+func claimJob(t *testing.T, jobID int64, now time.Time, lease time.Duration) (Job, bool) {
+	jobs, err := testQueries.ClaimJobs(context.Background(), ClaimJobsParams{
+		LockedUntil: now.Add(lease),
+		Now:         now,
+		BatchSize:   1000,
+	})
+	require.NoError(t, err)
+
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+func TestClaimJobs(t *testing.T) {
+	now := time.Now()
+	job := createRandomJob(t, now.Add(-time.Second))
+	future := createRandomJob(t, now.Add(time.Hour))
+
+	claimed, ok := claimJob(t, job.ID, now, time.Minute)
+	require.True(t, ok)
+	require.Equal(t, JobStatusRunning, claimed.Status)
+	require.Equal(t, int32(1), claimed.Attempts)
+	require.True(t, claimed.LockedUntil.Valid)
+
+	// a leased job is not handed out twice, and future jobs wait their turn
+	_, ok = claimJob(t, job.ID, now, time.Minute)
+	require.False(t, ok)
+	_, ok = claimJob(t, future.ID, now, time.Minute)
+	require.False(t, ok)
+
+	// once the lease runs out another worker takes the job over
+	claimed, ok = claimJob(t, job.ID, now.Add(2*time.Minute), time.Minute)
+	require.True(t, ok)
+	require.Equal(t, int32(2), claimed.Attempts)
+}
+
+func TestCompleteJob(t *testing.T) {
+	now := time.Now()
+	job := createRandomJob(t, now)
+	claimed, ok := claimJob(t, job.ID, now, time.Minute)
+	require.True(t, ok)
+
+	job, err := testQueries.CompleteJob(context.Background(), CompleteJobParams{
+		ID:          claimed.ID,
+		Attempts:    claimed.Attempts,
+		LockedUntil: claimed.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, JobStatusSucceeded, job.Status)
+	require.False(t, job.LockedUntil.Valid)
+
+	_, ok = claimJob(t, job.ID, now.Add(time.Hour), time.Minute)
+	require.False(t, ok)
+}
+
+func TestRetryJob(t *testing.T) {
+	now := time.Now()
+	job := createRandomJob(t, now)
+	claimed, ok := claimJob(t, job.ID, now, time.Minute)
+	require.True(t, ok)
+
+	job, err := testQueries.RetryJob(context.Background(), RetryJobParams{
+		ID:          claimed.ID,
+		Attempts:    claimed.Attempts,
+		LockedUntil: claimed.LockedUntil,
+		RunAt:       now.Add(time.Minute),
+		LastError:   "boom",
+	})
+	require.NoError(t, err)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Equal(t, "boom", job.LastError.String)
+
+	_, ok = claimJob(t, job.ID, now, time.Minute)
+	require.False(t, ok)
+	_, ok = claimJob(t, job.ID, now.Add(time.Minute), time.Minute)
+	require.True(t, ok)
+}
+
+func TestKillJob(t *testing.T) {
+	now := time.Now()
+	job := createRandomJob(t, now)
+	claimed, ok := claimJob(t, job.ID, now, time.Minute)
+	require.True(t, ok)
+
+	job, err := testQueries.KillJob(context.Background(), KillJobParams{
+		ID:          claimed.ID,
+		Attempts:    claimed.Attempts,
+		LockedUntil: claimed.LockedUntil,
+		LastError:   "boom",
+	})
+	require.NoError(t, err)
+	require.Equal(t, JobStatusDead, job.Status)
+
+	_, ok = claimJob(t, job.ID, now.Add(time.Hour), time.Minute)
+	require.False(t, ok)
+}
+
+func TestSettleJobLostLease(t *testing.T) {
+	now := time.Now()
+	job := createRandomJob(t, now)
+	stale, ok := claimJob(t, job.ID, now, time.Minute)
+	require.True(t, ok)
+
+	// the lease runs out and another worker claims the job
+	_, ok = claimJob(t, job.ID, now.Add(2*time.Minute), time.Minute)
+	require.True(t, ok)
+
+	_, err := testQueries.CompleteJob(context.Background(), CompleteJobParams{
+		ID:          stale.ID,
+		Attempts:    stale.Attempts,
+		LockedUntil: stale.LockedUntil,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.KillJob(context.Background(), KillJobParams{
+		ID:          stale.ID,
+		Attempts:    stale.Attempts,
+		LockedUntil: stale.LockedUntil,
+		LastError:   "boom",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	job, err = testQueries.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusRunning, job.Status)
+	require.Equal(t, int32(2), job.Attempts)
+}
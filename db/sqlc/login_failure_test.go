@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	username := util.RandOwner()
+	clientIP := util.RandomString(12)
+	arg := RecordLoginFailureParams{
+		Username:    username,
+		ClientIp:    clientIP,
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	for i := int32(1); i <= 3; i++ {
+		failures, err := testQueries.RecordLoginFailure(context.Background(), arg)
+		require.NoError(t, err)
+		require.Len(t, failures, 2)
+		for _, failure := range failures {
+			require.Equal(t, i, failure.Failures)
+			require.WithinDuration(t, time.Now(), failure.LastFailedAt, time.Second)
+		}
+	}
+
+	// failures before reset_before no longer count
+	arg.ResetBefore = time.Now().Add(time.Hour)
+	failures, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	for _, failure := range failures {
+		require.Equal(t, int32(1), failure.Failures)
+	}
+
+	got, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.ElementsMatch(t, failures, got)
+}
+
+func TestRecordLoginFailureConcurrent(t *testing.T) {
+	arg := RecordLoginFailureParams{
+		Username:    util.RandOwner(),
+		ClientIp:    util.RandomString(12),
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	// every failure is counted, even when recorded at the same time
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testQueries.RecordLoginFailure(context.Background(), arg)
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	failures, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{
+		Username: arg.Username,
+		ClientIp: arg.ClientIp,
+	})
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	for _, failure := range failures {
+		require.Equal(t, int32(n), failure.Failures)
+	}
+}
+
+func TestGetLoginFailuresNone(t *testing.T) {
+	failures, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{
+		Username: util.RandOwner(),
+		ClientIp: util.RandomString(12),
+	})
+	require.NoError(t, err)
+	require.Empty(t, failures)
+}
+
+func TestClearLoginFailures(t *testing.T) {
+	username := util.RandOwner()
+	clientIP := util.RandomString(12)
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Username:    username,
+		ClientIp:    clientIP,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.ClearLoginFailures(context.Background(), username)
+	require.NoError(t, err)
+
+	// failures of the client ip are kept
+	failures, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, "ip", failures[0].Scope)
+	require.Equal(t, clientIP, failures[0].Key)
+	require.Equal(t, int32(1), failures[0].Failures)
+}
@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,22 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64  `json:"overdraft_limit"`
+	Status         string `json:"status"`
+}
+
+type ApiKey struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	// sha256 of the key, the key itself is only shown once
+	KeyHash string `json:"key_hash"`
+	// what the key may be used for, never empty
+	Scopes    []string  `json:"scopes"`
+	IsRevoked bool      `json:"is_revoked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -24,16 +42,143 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that produced this entry, null for entries created before it was tracked
+	TransferID *int64 `json:"transfer_id"`
+}
+
+type FxRate struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// units of quote currency per unit of base currency
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Username       string          `json:"username"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	RequestHash    string          `json:"request_hash"`
+	Response       json.RawMessage `json:"response"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	// lease of the worker running the job, after which another worker may take it over
+	LockedUntil sql.NullTime   `json:"locked_until"`
+	LastError   sql.NullString `json:"last_error"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type LoginChallenge struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the challenge token handed out after the password check
+	TokenHash string    `json:"token_hash"`
+	Attempts  int32     `json:"attempts"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type LoginFailure struct {
+	// what key identifies, either a username or a client ip
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	// failed logins in a row, counted again from 1 once the last one is old enough
+	Failures     int32     `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+type OauthClient struct {
+	ClientID string `json:"client_id"`
+	// sha256 of the client secret, the secret itself is only shown once
+	SecretHash string `json:"secret_hash"`
+	Name       string `json:"name"`
+	// the user whose accounts the client acts on
+	Username string `json:"username"`
+	// the most a token of the client may be granted, never empty
+	Scopes     []string  `json:"scopes"`
+	IsDisabled bool      `json:"is_disabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token mailed to the user, the token itself is never stored
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the recovery code, the code itself is only shown once
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// standard five field cron rule, exclusive with interval_seconds
+	CronExpression  sql.NullString `json:"cron_expression"`
+	IntervalSeconds sql.NullInt64  `json:"interval_seconds"`
+	StartAt         time.Time      `json:"start_at"`
+	EndAt           sql.NullTime   `json:"end_at"`
+	MaxRuns         sql.NullInt32  `json:"max_runs"`
+	RunCount        int32          `json:"run_count"`
+	// null once the schedule has finished
+	NextRunAt sql.NullTime `json:"next_run_at"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64          `json:"id"`
+	ScheduledTransferID int64          `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time      `json:"scheduled_for"`
+	Status              string         `json:"status"`
+	TransferID          *int64         `json:"transfer_id"`
+	FailureReason       sql.NullString `json:"failure_reason"`
+	CreatedAt           time.Time      `json:"created_at"`
 }
 
 type Session struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	RefreshToken string    `json:"refresh_token"`
-	UserAgent    string    `json:"user_agent"`
-	ClientIp     string    `json:"client_ip"`
-	IsBlocked    bool      `json:"is_blocked"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID           uuid.UUID  `json:"id"`
+	Username     string     `json:"username"`
+	RefreshToken string     `json:"refresh_token"`
+	UserAgent    string     `json:"user_agent"`
+	ClientIp     string     `json:"client_ip"`
+	IsBlocked    bool       `json:"is_blocked"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FamilyID     uuid.UUID  `json:"family_id"`
+	ParentID     *uuid.UUID `json:"parent_id"`
+	IsRetired    bool       `json:"is_retired"`
+}
+
+type TotpCredential struct {
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+	IsEnabled bool   `json:"is_enabled"`
+	// newest TOTP time step accepted, older codes are rejected so they can't be replayed
+	LastUsedStep int64     `json:"last_used_step"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -44,6 +189,12 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// must be positive, in the destination account currency
+	ToAmount      int64     `json:"to_amount"`
+	ExchangeRate  string    `json:"exchange_rate"`
+	RateTimestamp time.Time `json:"rate_timestamp"`
+	// transfer that this one refunds, null for ordinary transfers
+	ReversalOfID *int64 `json:"reversal_of_id"`
 }
 
 type User struct {
@@ -53,4 +204,17 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// sha256 of the code mailed to the user, the code itself is never stored
+	SecretCodeHash string    `json:"secret_code_hash"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
 }
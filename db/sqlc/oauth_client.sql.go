@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: oauth_client.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const createOAuthClient = `-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    client_id,
+    secret_hash,
+    name,
+    username,
+    scopes
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING client_id, secret_hash, name, username, scopes, is_disabled, created_at
+`
+
+type CreateOAuthClientParams struct {
+	ClientID   string   `json:"client_id"`
+	SecretHash string   `json:"secret_hash"`
+	Name       string   `json:"name"`
+	Username   string   `json:"username"`
+	Scopes     []string `json:"scopes"`
+}
+
+func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, createOAuthClient,
+		arg.ClientID,
+		arg.SecretHash,
+		arg.Name,
+		arg.Username,
+		pq.Array(arg.Scopes),
+	)
+	var i OauthClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.SecretHash,
+		&i.Name,
+		&i.Username,
+		pq.Array(&i.Scopes),
+		&i.IsDisabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const disableOAuthClient = `-- name: DisableOAuthClient :one
+UPDATE oauth_clients
+SET is_disabled = TRUE
+WHERE client_id = $1
+RETURNING client_id, secret_hash, name, username, scopes, is_disabled, created_at
+`
+
+func (q *Queries) DisableOAuthClient(ctx context.Context, clientID string) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, disableOAuthClient, clientID)
+	var i OauthClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.SecretHash,
+		&i.Name,
+		&i.Username,
+		pq.Array(&i.Scopes),
+		&i.IsDisabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOAuthClient = `-- name: GetOAuthClient :one
+SELECT client_id, secret_hash, name, username, scopes, is_disabled, created_at FROM oauth_clients
+WHERE client_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetOAuthClient(ctx context.Context, clientID string) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthClient, clientID)
+	var i OauthClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.SecretHash,
+		&i.Name,
+		&i.Username,
+		pq.Array(&i.Scopes),
+		&i.IsDisabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listOAuthClients = `-- name: ListOAuthClients :many
+SELECT client_id, secret_hash, name, username, scopes, is_disabled, created_at FROM oauth_clients
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListOAuthClients(ctx context.Context) ([]OauthClient, error) {
+	rows, err := q.db.QueryContext(ctx, listOAuthClients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OauthClient{}
+	for rows.Next() {
+		var i OauthClient
+		if err := rows.Scan(
+			&i.ClientID,
+			&i.SecretHash,
+			&i.Name,
+			&i.Username,
+			pq.Array(&i.Scopes),
+			&i.IsDisabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
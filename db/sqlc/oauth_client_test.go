@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOAuthClient(t *testing.T, user User) OauthClient {
+	arg := CreateOAuthClientParams{
+		ClientID:   util.RandomString(16),
+		SecretHash: util.HashSecret(util.RandomString(32)),
+		Name:       util.RandomString(6),
+		Username:   user.Username,
+		Scopes:     []string{"accounts:read"},
+	}
+
+	client, err := testQueries.CreateOAuthClient(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ClientID, client.ClientID)
+	require.Equal(t, arg.SecretHash, client.SecretHash)
+	require.Equal(t, arg.Name, client.Name)
+	require.Equal(t, arg.Username, client.Username)
+	require.Equal(t, arg.Scopes, client.Scopes)
+	require.False(t, client.IsDisabled)
+	require.NotZero(t, client.CreatedAt)
+
+	return client
+}
+
+func TestGetOAuthClient(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, user)
+
+	got, err := testQueries.GetOAuthClient(context.Background(), client.ClientID)
+	require.NoError(t, err)
+	require.Equal(t, client.ClientID, got.ClientID)
+	require.Equal(t, client.Scopes, got.Scopes)
+	require.Equal(t, user.Username, got.Username)
+
+	_, err = testQueries.GetOAuthClient(context.Background(), util.RandomString(16))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDisableOAuthClient(t *testing.T) {
+	client := createRandomOAuthClient(t, createRandomUser(t))
+
+	disabled, err := testQueries.DisableOAuthClient(context.Background(), client.ClientID)
+	require.NoError(t, err)
+	require.True(t, disabled.IsDisabled)
+
+	clients, err := testQueries.ListOAuthClients(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, clients)
+}
@@ -0,0 +1,76 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: password_reset_token.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (
+    username,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, username, token_hash, is_used, created_at, expired_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordResetToken, arg.Username, arg.TokenHash, arg.ExpiredAt)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const getPasswordResetTokenForUpdate = `-- name: GetPasswordResetTokenForUpdate :one
+SELECT id, username, token_hash, is_used, created_at, expired_at
+FROM password_reset_tokens
+WHERE token_hash = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetTokenForUpdate, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const useUserPasswordResetTokens = `-- name: UseUserPasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET is_used = TRUE
+WHERE username = $1
+    AND is_used = FALSE
+`
+
+// A reset uses up every token the user still holds, not only the one
+// that was redeemed.
+func (q *Queries) UseUserPasswordResetTokens(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, useUserPasswordResetTokens, username)
+	return err
+}
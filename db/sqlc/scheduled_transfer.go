@@ -0,0 +1,30 @@
+package db
+
+// Scheduled transfer statuses. Only active schedules are run; cancelled and
+// completed are terminal.
+const (
+	ScheduledTransferStatusActive    = "active"
+	ScheduledTransferStatusPaused    = "paused"
+	ScheduledTransferStatusCancelled = "cancelled"
+	ScheduledTransferStatusCompleted = "completed"
+)
+
+// Outcomes of a single scheduled transfer run.
+const (
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+)
+
+// ScheduledTransferSourceStatuses lists the statuses a schedule may be moved
+// to status from by its owner.
+func ScheduledTransferSourceStatuses(status string) []string {
+	switch status {
+	case ScheduledTransferStatusPaused:
+		return []string{ScheduledTransferStatusActive}
+	case ScheduledTransferStatusActive:
+		return []string{ScheduledTransferStatusPaused}
+	case ScheduledTransferStatusCancelled:
+		return []string{ScheduledTransferStatusActive, ScheduledTransferStatusPaused}
+	}
+	return nil
+}
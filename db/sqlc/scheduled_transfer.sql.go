@@ -0,0 +1,376 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: scheduled_transfer.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createScheduledTransfer = `-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    cron_expression,
+    interval_seconds,
+    start_at,
+    end_at,
+    max_runs,
+    next_run_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+`
+
+type CreateScheduledTransferParams struct {
+	Owner           string         `json:"owner"`
+	FromAccountID   int64          `json:"from_account_id"`
+	ToAccountID     int64          `json:"to_account_id"`
+	Amount          int64          `json:"amount"`
+	Currency        string         `json:"currency"`
+	CronExpression  sql.NullString `json:"cron_expression"`
+	IntervalSeconds sql.NullInt64  `json:"interval_seconds"`
+	StartAt         time.Time      `json:"start_at"`
+	EndAt           sql.NullTime   `json:"end_at"`
+	MaxRuns         sql.NullInt32  `json:"max_runs"`
+	NextRunAt       sql.NullTime   `json:"next_run_at"`
+}
+
+func (q *Queries) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledTransfer,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.CronExpression,
+		arg.IntervalSeconds,
+		arg.StartAt,
+		arg.EndAt,
+		arg.MaxRuns,
+		arg.NextRunAt,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CronExpression,
+		&i.IntervalSeconds,
+		&i.StartAt,
+		&i.EndAt,
+		&i.MaxRuns,
+		&i.RunCount,
+		&i.NextRunAt,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createScheduledTransferRun = `-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+    scheduled_transfer_id,
+    scheduled_for,
+    status,
+    transfer_id,
+    failure_reason
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, scheduled_transfer_id, scheduled_for, status, transfer_id, failure_reason, created_at
+`
+
+type CreateScheduledTransferRunParams struct {
+	ScheduledTransferID int64          `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time      `json:"scheduled_for"`
+	Status              string         `json:"status"`
+	TransferID          *int64         `json:"transfer_id"`
+	FailureReason       sql.NullString `json:"failure_reason"`
+}
+
+func (q *Queries) CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledTransferRun,
+		arg.ScheduledTransferID,
+		arg.ScheduledFor,
+		arg.Status,
+		arg.TransferID,
+		arg.FailureReason,
+	)
+	var i ScheduledTransferRun
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledTransferID,
+		&i.ScheduledFor,
+		&i.Status,
+		&i.TransferID,
+		&i.FailureReason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getScheduledTransfer = `-- name: GetScheduledTransfer :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransfer, id)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CronExpression,
+		&i.IntervalSeconds,
+		&i.StartAt,
+		&i.EndAt,
+		&i.MaxRuns,
+		&i.RunCount,
+		&i.NextRunAt,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getScheduledTransferForUpdate = `-- name: GetScheduledTransferForUpdate :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransferForUpdate, id)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CronExpression,
+		&i.IntervalSeconds,
+		&i.StartAt,
+		&i.EndAt,
+		&i.MaxRuns,
+		&i.RunCount,
+		&i.NextRunAt,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDueScheduledTransfers = `-- name: ListDueScheduledTransfers :many
+SELECT id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+FROM scheduled_transfers
+WHERE status = 'active' AND next_run_at <= $1::timestamptz
+ORDER BY next_run_at
+LIMIT $2
+`
+
+type ListDueScheduledTransfersParams struct {
+	Now       time.Time `json:"now"`
+	PageLimit int32     `json:"page_limit"`
+}
+
+func (q *Queries) ListDueScheduledTransfers(ctx context.Context, arg ListDueScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listDueScheduledTransfers, arg.Now, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransfer{}
+	for rows.Next() {
+		var i ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.CronExpression,
+			&i.IntervalSeconds,
+			&i.StartAt,
+			&i.EndAt,
+			&i.MaxRuns,
+			&i.RunCount,
+			&i.NextRunAt,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledTransfers = `-- name: ListScheduledTransfers :many
+SELECT id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+FROM scheduled_transfers
+WHERE owner = $1
+    AND ($2::timestamptz IS NULL
+        OR (created_at, id) > ($2::timestamptz, $3::bigint))
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListScheduledTransfersParams struct {
+	Owner           string        `json:"owner"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        sql.NullInt64 `json:"cursor_id"`
+	PageLimit       int32         `json:"page_limit"`
+}
+
+func (q *Queries) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTransfers,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransfer{}
+	for rows.Next() {
+		var i ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.CronExpression,
+			&i.IntervalSeconds,
+			&i.StartAt,
+			&i.EndAt,
+			&i.MaxRuns,
+			&i.RunCount,
+			&i.NextRunAt,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateScheduledTransferAfterRun = `-- name: UpdateScheduledTransferAfterRun :one
+UPDATE scheduled_transfers
+SET
+    run_count = run_count + 1,
+    next_run_at = $1,
+    status = CASE WHEN $1::timestamptz IS NULL THEN 'completed' ELSE status END
+WHERE id = $2
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+`
+
+type UpdateScheduledTransferAfterRunParams struct {
+	NextRunAt sql.NullTime `json:"next_run_at"`
+	ID        int64        `json:"id"`
+}
+
+func (q *Queries) UpdateScheduledTransferAfterRun(ctx context.Context, arg UpdateScheduledTransferAfterRunParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledTransferAfterRun, arg.NextRunAt, arg.ID)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CronExpression,
+		&i.IntervalSeconds,
+		&i.StartAt,
+		&i.EndAt,
+		&i.MaxRuns,
+		&i.RunCount,
+		&i.NextRunAt,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateScheduledTransferStatus = `-- name: UpdateScheduledTransferStatus :one
+UPDATE scheduled_transfers
+SET
+    status = $1,
+    next_run_at = COALESCE($2, next_run_at)
+WHERE id = $3 AND status = ANY($4::varchar[])
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, cron_expression, interval_seconds, start_at, end_at, max_runs, run_count, next_run_at, status, created_at
+`
+
+type UpdateScheduledTransferStatusParams struct {
+	Status       string       `json:"status"`
+	NextRunAt    sql.NullTime `json:"next_run_at"`
+	ID           int64        `json:"id"`
+	FromStatuses []string     `json:"from_statuses"`
+}
+
+func (q *Queries) UpdateScheduledTransferStatus(ctx context.Context, arg UpdateScheduledTransferStatusParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledTransferStatus,
+		arg.Status,
+		arg.NextRunAt,
+		arg.ID,
+		pq.Array(arg.FromStatuses),
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CronExpression,
+		&i.IntervalSeconds,
+		&i.StartAt,
+		&i.EndAt,
+		&i.MaxRuns,
+		&i.RunCount,
+		&i.NextRunAt,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	id := uuid.New()
+	session, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		ID:           id,
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		FamilyID:     id,
+	})
+	require.NoError(t, err)
+	require.False(t, session.IsBlocked)
+	require.False(t, session.IsRetired)
+	require.Equal(t, id, session.FamilyID)
+	require.Nil(t, session.ParentID)
+
+	return session
+}
+
+func TestListActiveSessions(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+	blocked := createRandomSession(t, user)
+	createRandomSession(t, createRandomUser(t))
+
+	_, err := testQueries.BlockSession(context.Background(), blocked.ID)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListActiveSessions(context.Background(), ListActiveSessionsParams{
+		Username: user.Username,
+		Now:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	// newest first
+	require.Equal(t, session2.ID, sessions[0].ID)
+	require.Equal(t, session1.ID, sessions[1].ID)
+
+	// sessions past their expiry are not active
+	sessions, err = testQueries.ListActiveSessions(context.Background(), ListActiveSessionsParams{
+		Username: user.Username,
+		Now:      time.Now().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomSession(t, user)
+	createRandomSession(t, user)
+	other := createRandomSession(t, createRandomUser(t))
+
+	err := testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListActiveSessions(context.Background(), ListActiveSessionsParams{
+		Username: user.Username,
+		Now:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsBlocked)
+}
+
+func TestRetireSession(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+
+	retired, err := testQueries.RetireSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, retired.IsRetired)
+
+	// a session can only be retired once
+	_, err = testQueries.RetireSession(context.Background(), session.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// retired sessions are not active
+	sessions, err := testQueries.ListActiveSessions(context.Background(), ListActiveSessionsParams{
+		Username: session.Username,
+		Now:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestBlockSessionFamily(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	root := createRandomSession(t, user)
+	other := createRandomSession(t, user)
+
+	child, err := store.RenewSessionTx(context.Background(), RenewSessionTxParams{
+		ParentID:     root.ID,
+		ID:           uuid.New(),
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.BlockSessionFamily(context.Background(), root.FamilyID)
+	require.NoError(t, err)
+
+	for _, id := range []uuid.UUID{root.ID, child.ID} {
+		session, err := testQueries.GetSession(context.Background(), id)
+		require.NoError(t, err)
+		require.True(t, session.IsBlocked)
+	}
+
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsBlocked)
+}
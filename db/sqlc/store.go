@@ -3,11 +3,28 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 )
 
+// ErrInsufficientFunds is returned when a transfer would take the source
+// account below its overdraft limit.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Store interface {
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (TransferTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (User, error)
+	RenewSessionTx(ctx context.Context, arg RenewSessionTxParams) (Session, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error)
+	CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (User, error)
 	Querier
 }
 
@@ -42,10 +59,17 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	return tx.Commit()
 }
 
+// TransferTxParams describes a transfer. Amount is debited in the source
+// account currency. For cross-currency transfers ToAmount is credited in the
+// destination account currency at ExchangeRate; when ExchangeRate is empty
+// both accounts are assumed to share a currency and ToAmount equals Amount.
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	ToAmount      int64     `json:"to_amount"`
+	ExchangeRate  string    `json:"exchange_rate"`
+	RateTimestamp time.Time `json:"rate_timestamp"`
 }
 
 type TransferTxResult struct {
@@ -60,45 +84,80 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	var result TransferTxResult
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg, nil)
+		return err
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			Amount:    -arg.Amount,
-			AccountID: arg.FromAccountID,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves money between two accounts using q, which must be bound
+// to a transaction. It is shared by every Tx that creates a transfer.
+// reversalOfID links the new transfer to the one it refunds, if any.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, reversalOfID *int64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			Amount:    arg.Amount,
-			AccountID: arg.ToAccountID,
-		})
-		if err != nil {
-			return err
-		}
-		
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
-		if err != nil {
-			return err
-		}
+	if arg.ExchangeRate == "" {
+		arg.ToAmount = arg.Amount
+		arg.ExchangeRate = "1"
+		arg.RateTimestamp = time.Now()
+	}
 
-		return nil
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      arg.ToAmount,
+		ExchangeRate:  arg.ExchangeRate,
+		RateTimestamp: arg.RateTimestamp,
+		ReversalOfID:  reversalOfID,
 	})
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		Amount:     -arg.Amount,
+		AccountID:  arg.FromAccountID,
+		TransferID: &result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		Amount:     arg.ToAmount,
+		AccountID:  arg.ToAccountID,
+		TransferID: &result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ToAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.ToAmount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// The balance updates above hold the row locks on both accounts,
+	// so neither can change status or balance before these checks commit.
+	if result.FromAccount.Status != AccountStatusActive {
+		return result, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, result.FromAccount.ID, result.FromAccount.Status)
+	}
+	if result.ToAccount.Status != AccountStatusActive {
+		return result, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, result.ToAccount.ID, result.ToAccount.Status)
+	}
+
+	if result.FromAccount.Balance < -result.FromAccount.OverdraftLimit {
+		return result, ErrInsufficientFunds
+	}
+
+	return result, nil
 }
 
 func addMoney(
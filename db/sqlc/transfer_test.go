@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -13,10 +14,14 @@ func CreateTransfer(t *testing.T) Transfer {
 	account1 := CreateAccount(t)
 	account2 := CreateAccount(t)
 
+	amount := util.RandMoney()
 	arg := CreateTransferParams{
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
-		Amount:        util.RandMoney(),
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
+		RateTimestamp: time.Now(),
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -58,8 +63,7 @@ func TestListTransfers(t *testing.T) {
 	}
 
 	arg := ListTransfersParams{
-		Limit:  5,
-		Offset: 5,
+		PageLimit: 5,
 	}
 
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
@@ -70,3 +74,47 @@ func TestListTransfers(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestListAccountTransfers(t *testing.T) {
+	account1 := CreateAccount(t)
+	account2 := CreateAccount(t)
+	account3 := CreateAccount(t)
+
+	var created []Transfer
+	for _, pair := range [][2]Account{{account1, account2}, {account2, account1}, {account1, account3}} {
+		transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+			FromAccountID: pair[0].ID,
+			ToAccountID:   pair[1].ID,
+			Amount:        10,
+			ToAmount:      10,
+			ExchangeRate:  "1",
+			RateTimestamp: time.Now(),
+		})
+		require.NoError(t, err)
+		created = append(created, transfer)
+	}
+
+	arg := ListTransfersParams{
+		AccountID: sql.NullInt64{Int64: account2.ID, Valid: true},
+		PageLimit: 5,
+	}
+
+	transfers, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, created[0].ID, transfers[0].ID)
+	require.Equal(t, created[1].ID, transfers[1].ID)
+
+	arg = ListTransfersParams{
+		AccountID:       sql.NullInt64{Int64: account1.ID, Valid: true},
+		CursorCreatedAt: sql.NullTime{Time: created[0].CreatedAt, Valid: true},
+		CursorID:        sql.NullInt64{Int64: created[0].ID, Valid: true},
+		PageLimit:       5,
+	}
+
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, created[1].ID, transfers[0].ID)
+	require.Equal(t, created[2].ID, transfers[1].ID)
+}
@@ -0,0 +1,277 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: two_factor.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const addLoginChallengeAttempt = `-- name: AddLoginChallengeAttempt :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING id, username, token_hash, attempts, is_used, created_at, expired_at
+`
+
+func (q *Queries) AddLoginChallengeAttempt(ctx context.Context, id int64) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, addLoginChallengeAttempt, id)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const createLoginChallenge = `-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    username,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, username, token_hash, attempts, is_used, created_at, expired_at
+`
+
+type CreateLoginChallengeParams struct {
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, createLoginChallenge, arg.Username, arg.TokenHash, arg.ExpiredAt)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    username,
+    code_hash
+) VALUES (
+    $1, $2
+) RETURNING id, username, code_hash, is_used, created_at
+`
+
+type CreateRecoveryCodeParams struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, createRecoveryCode, arg.Username, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTOTPCredential = `-- name: CreateTOTPCredential :one
+INSERT INTO totp_credentials (
+    username,
+    secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE totp_credentials.is_enabled = FALSE
+RETURNING username, secret, is_enabled, last_used_step, created_at
+`
+
+type CreateTOTPCredentialParams struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+}
+
+// Starting over replaces a pending secret, but never an enabled one.
+func (q *Queries) CreateTOTPCredential(ctx context.Context, arg CreateTOTPCredentialParams) (TotpCredential, error) {
+	row := q.db.QueryRowContext(ctx, createTOTPCredential, arg.Username, arg.Secret)
+	var i TotpCredential
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, username)
+	return err
+}
+
+const enableTOTPCredential = `-- name: EnableTOTPCredential :one
+UPDATE totp_credentials
+SET is_enabled = TRUE,
+    last_used_step = $1
+WHERE username = $2
+RETURNING username, secret, is_enabled, last_used_step, created_at
+`
+
+type EnableTOTPCredentialParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) EnableTOTPCredential(ctx context.Context, arg EnableTOTPCredentialParams) (TotpCredential, error) {
+	row := q.db.QueryRowContext(ctx, enableTOTPCredential, arg.Step, arg.Username)
+	var i TotpCredential
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLoginChallengeForUpdate = `-- name: GetLoginChallengeForUpdate :one
+SELECT id, username, token_hash, attempts, is_used, created_at, expired_at FROM login_challenges
+WHERE token_hash = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetLoginChallengeForUpdate(ctx context.Context, tokenHash string) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, getLoginChallengeForUpdate, tokenHash)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const getTOTPCredential = `-- name: GetTOTPCredential :one
+SELECT username, secret, is_enabled, last_used_step, created_at FROM totp_credentials
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetTOTPCredential(ctx context.Context, username string) (TotpCredential, error) {
+	row := q.db.QueryRowContext(ctx, getTOTPCredential, username)
+	var i TotpCredential
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTOTPCredentialForUpdate = `-- name: GetTOTPCredentialForUpdate :one
+SELECT username, secret, is_enabled, last_used_step, created_at FROM totp_credentials
+WHERE username = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetTOTPCredentialForUpdate(ctx context.Context, username string) (TotpCredential, error) {
+	row := q.db.QueryRowContext(ctx, getTOTPCredentialForUpdate, username)
+	var i TotpCredential
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useLoginChallenge = `-- name: UseLoginChallenge :exec
+UPDATE login_challenges
+SET is_used = TRUE
+WHERE id = $1
+`
+
+func (q *Queries) UseLoginChallenge(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, useLoginChallenge, id)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET is_used = TRUE
+WHERE username = $1
+    AND code_hash = $2
+    AND is_used = FALSE
+RETURNING id, username, code_hash, is_used, created_at
+`
+
+type UseRecoveryCodeParams struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, useRecoveryCode, arg.Username, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE totp_credentials
+SET last_used_step = $1
+WHERE username = $2
+    AND last_used_step < $1
+`
+
+type UseTOTPStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.Step, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,31 @@
+package db
+
+import "context"
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// AfterCreate runs inside the transaction once the user row is written,
+	// so that follow-up work such as enqueueing jobs commits or rolls back
+	// together with the user.
+	AfterCreate func(q Querier, user User) error
+}
+
+// CreateUserTx creates a user and runs AfterCreate in the same transaction.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate == nil {
+			return nil
+		}
+		return arg.AfterCreate(q, user)
+	})
+
+	return user, err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomCreateUserParams(t *testing.T) CreateUserParams {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	return CreateUserParams{
+		Username:       util.RandOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandOwner(),
+		Email:          util.RandomEmail(),
+	}
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	arg := randomCreateUserParams(t)
+
+	var job Job
+	user, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: arg,
+		AfterCreate: func(q Querier, user User) error {
+			var err error
+			job, err = q.CreateJob(context.Background(), CreateJobParams{
+				Kind:        "test",
+				Payload:     []byte(`{"username":"` + user.Username + `"}`),
+				MaxAttempts: 1,
+				RunAt:       time.Now(),
+			})
+			return err
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+
+	// the job was committed together with the user
+	job, err = store.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusPending, job.Status)
+}
+
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	arg := randomCreateUserParams(t)
+
+	afterCreateErr := errors.New("cannot enqueue job")
+	_, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: arg,
+		AfterCreate: func(q Querier, user User) error {
+			return afterCreateErr
+		},
+	})
+	require.ErrorIs(t, err, afterCreateErr)
+
+	// a failing AfterCreate leaves no user behind
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is replayed
+// with a request that differs from the one it was first used with.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+type IdempotentTransferTxParams struct {
+	TransferTxParams
+	// Currency is the currency the client sent the request in.
+	Currency       string `json:"currency"`
+	Username       string `json:"username"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// IdempotentTransferTx performs a money transfer at most once per
+// (username, idempotency key). A replay of the same request returns the
+// result stored by the first call instead of moving money again.
+func (store *SQLStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	requestHash := transferRequestHash(arg)
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// A concurrent call holding the same key blocks this insert until it
+		// commits or rolls back, so only one of them can run the transfer.
+		_, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:       arg.Username,
+			IdempotencyKey: arg.IdempotencyKey,
+			RequestHash:    requestHash,
+		})
+		if err == sql.ErrNoRows {
+			key, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+				Username:       arg.Username,
+				IdempotencyKey: arg.IdempotencyKey,
+			})
+			if err != nil {
+				return err
+			}
+			if key.RequestHash != requestHash {
+				return ErrIdempotencyKeyReused
+			}
+			return json.Unmarshal(key.Response, &result)
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err = transfer(ctx, q, arg.TransferTxParams, nil)
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+			Response:       response,
+			Username:       arg.Username,
+			IdempotencyKey: arg.IdempotencyKey,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// transferRequestHash fingerprints every field of the request, the quote
+// a cross-currency transfer is converted with included, so that replays can
+// be told apart from different requests.
+func transferRequestHash(arg IdempotentTransferTxParams) string {
+	data := fmt.Sprintf("%d:%d:%d:%s:%d:%s:%s",
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.RateTimestamp.UTC().Format(time.RFC3339Nano),
+	)
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
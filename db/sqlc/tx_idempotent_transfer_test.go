@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		},
+		Username:       account1.Owner,
+		IdempotencyKey: util.RandomString(16),
+	}
+
+	result1, err := store.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result1.Transfer.ID)
+
+	// replaying the same request returns the original result
+	result2, err := store.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+	require.Equal(t, result1.ToAccount.Balance, result2.ToAccount.Balance)
+
+	// money only moved once
+	fromAccount, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, fromAccount.Balance)
+
+	toAccount, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+arg.Amount, toAccount.Balance)
+}
+
+func TestIdempotentTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		},
+		Username:       account1.Owner,
+		IdempotencyKey: util.RandomString(16),
+	}
+
+	n := 5
+	errs := make(chan error)
+	results := make(chan TransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.IdempotentTransferTx(context.Background(), arg)
+			errs <- err
+			results <- result
+		}()
+	}
+
+	transferIDs := make(map[int64]bool)
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+		transferIDs[result.Transfer.ID] = true
+	}
+	require.Len(t, transferIDs, 1)
+
+	fromAccount, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, fromAccount.Balance)
+}
+
+func TestIdempotentTransferTxKeyReused(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		},
+		Currency:       account1.Currency,
+		Username:       account1.Owner,
+		IdempotencyKey: util.RandomString(16),
+	}
+
+	_, err := store.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	changed := arg
+	changed.ToAmount = 9
+	changed.ExchangeRate = "0.9"
+	changed.RateTimestamp = time.Now()
+	_, err = store.IdempotentTransferTx(context.Background(), changed)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	// money only moved for the first request
+	fromAccount, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, fromAccount.Balance)
+}
+
+func TestTransferRequestHash(t *testing.T) {
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: 1,
+			ToAccountID:   2,
+			Amount:        100,
+			ToAmount:      90,
+			ExchangeRate:  "0.9",
+			RateTimestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Currency:       util.USD,
+		Username:       util.RandOwner(),
+		IdempotencyKey: util.RandomString(16),
+	}
+	hash := transferRequestHash(arg)
+
+	// the key itself is not part of the request
+	same := arg
+	same.IdempotencyKey = util.RandomString(16)
+	same.RateTimestamp = arg.RateTimestamp.In(time.FixedZone("UTC+1", 3600))
+	require.Equal(t, hash, transferRequestHash(same))
+
+	testCases := []struct {
+		name   string
+		change func(arg *IdempotentTransferTxParams)
+	}{
+		{
+			name:   "FromAccountID",
+			change: func(arg *IdempotentTransferTxParams) { arg.FromAccountID = 3 },
+		},
+		{
+			name:   "ToAccountID",
+			change: func(arg *IdempotentTransferTxParams) { arg.ToAccountID = 3 },
+		},
+		{
+			name:   "Amount",
+			change: func(arg *IdempotentTransferTxParams) { arg.Amount = 200 },
+		},
+		{
+			name:   "Currency",
+			change: func(arg *IdempotentTransferTxParams) { arg.Currency = util.EUR },
+		},
+		{
+			name:   "ToAmount",
+			change: func(arg *IdempotentTransferTxParams) { arg.ToAmount = 91 },
+		},
+		{
+			name:   "ExchangeRate",
+			change: func(arg *IdempotentTransferTxParams) { arg.ExchangeRate = "0.91" },
+		},
+		{
+			name:   "RateTimestamp",
+			change: func(arg *IdempotentTransferTxParams) { arg.RateTimestamp = arg.RateTimestamp.Add(time.Second) },
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			changed := arg
+			tc.change(&changed)
+			require.NotEqual(t, hash, transferRequestHash(changed))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionRetired is returned when the session being renewed was already
+// renewed, or blocked, by someone else.
+var ErrSessionRetired = errors.New("session was already renewed")
+
+type RenewSessionTxParams struct {
+	ParentID     uuid.UUID `json:"parent_id"`
+	ID           uuid.UUID `json:"id"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RenewSessionTx retires the parent session and replaces it with a new one
+// in the same family. Only one renewal of a session can ever succeed; the
+// caller should treat ErrSessionRetired as refresh token reuse.
+func (store *SQLStore) RenewSessionTx(ctx context.Context, arg RenewSessionTxParams) (Session, error) {
+	var session Session
+	err := store.execTx(ctx, func(q *Queries) error {
+		parent, err := q.RetireSession(ctx, arg.ParentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSessionRetired
+			}
+			return err
+		}
+
+		session, err = q.CreateSession(ctx, CreateSessionParams{
+			ID:           arg.ID,
+			Username:     parent.Username,
+			RefreshToken: arg.RefreshToken,
+			UserAgent:    arg.UserAgent,
+			ClientIp:     arg.ClientIp,
+			ExpiresAt:    arg.ExpiresAt,
+			FamilyID:     parent.FamilyID,
+			ParentID:     &parent.ID,
+		})
+		return err
+	})
+
+	return session, err
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomRenewSessionTxParams(parent Session) RenewSessionTxParams {
+	return RenewSessionTxParams{
+		ParentID:     parent.ID,
+		ID:           uuid.New(),
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "renewed",
+		ClientIp:     "127.0.0.2",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+}
+
+func TestRenewSessionTx(t *testing.T) {
+	store := NewStore(testDB)
+	parent := createRandomSession(t, createRandomUser(t))
+
+	arg := randomRenewSessionTxParams(parent)
+	session, err := store.RenewSessionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, parent.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.Equal(t, parent.FamilyID, session.FamilyID)
+	require.NotNil(t, session.ParentID)
+	require.Equal(t, parent.ID, *session.ParentID)
+	require.False(t, session.IsRetired)
+
+	parent, err = store.GetSession(context.Background(), parent.ID)
+	require.NoError(t, err)
+	require.True(t, parent.IsRetired)
+	require.False(t, parent.IsBlocked)
+
+	// the new session can be renewed in turn, staying in the same family
+	grandchild, err := store.RenewSessionTx(context.Background(), randomRenewSessionTxParams(session))
+	require.NoError(t, err)
+	require.Equal(t, parent.FamilyID, grandchild.FamilyID)
+	require.Equal(t, session.ID, *grandchild.ParentID)
+}
+
+func TestRenewSessionTxRetired(t *testing.T) {
+	store := NewStore(testDB)
+	parent := createRandomSession(t, createRandomUser(t))
+
+	_, err := store.RenewSessionTx(context.Background(), randomRenewSessionTxParams(parent))
+	require.NoError(t, err)
+
+	arg := randomRenewSessionTxParams(parent)
+	_, err = store.RenewSessionTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrSessionRetired)
+
+	_, err = store.GetSession(context.Background(), arg.ID)
+	require.Error(t, err)
+}
+
+func TestRenewSessionTxBlocked(t *testing.T) {
+	store := NewStore(testDB)
+	parent := createRandomSession(t, createRandomUser(t))
+
+	_, err := store.BlockSession(context.Background(), parent.ID)
+	require.NoError(t, err)
+
+	_, err = store.RenewSessionTx(context.Background(), randomRenewSessionTxParams(parent))
+	require.ErrorIs(t, err, ErrSessionRetired)
+}
+
+func TestRenewSessionTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	parent := createRandomSession(t, createRandomUser(t))
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		arg := randomRenewSessionTxParams(parent)
+		go func() {
+			_, err := store.RenewSessionTx(context.Background(), arg)
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrSessionRetired)
+	}
+	require.Equal(t, 1, succeeded)
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+)
+
+var (
+	ErrPasswordResetTokenInvalid = errors.New("invalid password reset token")
+	ErrPasswordResetTokenUsed    = errors.New("password reset token was already used")
+	ErrPasswordResetTokenExpired = errors.New("password reset token has expired")
+)
+
+type ResetPasswordTxParams struct {
+	Token          string `json:"token"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ResetPasswordTx redeems a password reset token and sets a new password.
+// Every reset token the user still holds is used up and all their sessions
+// are blocked, so whoever knew the old password is signed out everywhere.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		resetToken, err := q.GetPasswordResetTokenForUpdate(ctx, util.HashSecret(arg.Token))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrPasswordResetTokenInvalid
+			}
+			return err
+		}
+
+		if resetToken.IsUsed {
+			return ErrPasswordResetTokenUsed
+		}
+		if time.Now().After(resetToken.ExpiredAt) {
+			return ErrPasswordResetTokenExpired
+		}
+
+		err = q.UseUserPasswordResetTokens(ctx, resetToken.Username)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       resetToken.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, resetToken.Username)
+	})
+
+	return user, err
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, user User, expiredAt time.Time) string {
+	token, err := util.RandomSecret(32)
+	require.NoError(t, err)
+
+	_, err = testQueries.CreatePasswordResetToken(context.Background(), CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(token),
+		ExpiredAt: expiredAt,
+	})
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	token1 := createRandomPasswordResetToken(t, user, time.Now().Add(time.Hour))
+	token2 := createRandomPasswordResetToken(t, user, time.Now().Add(time.Hour))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          "wrong",
+		HashedPassword: hashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenInvalid)
+
+	updatedUser, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          token1,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updatedUser.HashedPassword)
+	require.WithinDuration(t, time.Now(), updatedUser.PasswordChangedAt, time.Minute)
+
+	session, err = store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	// the redeemed token and every other outstanding one are used up
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          token1,
+		HashedPassword: hashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          token2,
+		HashedPassword: hashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	token := createRandomPasswordResetToken(t, user, time.Now().Add(-time.Second))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          token,
+		HashedPassword: user.HashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenExpired)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrTransferIsReversal    = errors.New("a reversal cannot itself be reversed")
+	ErrRefundExceedsTransfer = errors.New("refund exceeds the amount left on the transfer")
+	ErrRefundAmountTooSmall  = errors.New("refund amount is too small to convert back to the source currency")
+)
+
+// ReverseTransferTxParams describes a refund of a transfer. Amount is in the
+// currency of the account that received the original transfer; zero refunds
+// whatever has not been refunded yet.
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	Amount     int64 `json:"amount"`
+}
+
+type ReverseTransferTxResult struct {
+	TransferTxResult
+	OriginalTransfer Transfer `json:"original_transfer"`
+	// RefundedAmount is the total refunded on the original transfer,
+	// including this reversal.
+	RefundedAmount int64 `json:"refunded_amount"`
+}
+
+// ReverseTransferTx sends money back from the receiver of a transfer to its
+// sender with a new transfer linked by reversal_of_id. Several partial
+// reversals are allowed as long as together they never exceed the amount
+// originally received. The original transfer row is locked so concurrent
+// reversals are serialised before the remaining amount is checked.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.OriginalTransfer, err = q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		original := result.OriginalTransfer
+
+		if original.ReversalOfID != nil {
+			return ErrTransferIsReversal
+		}
+
+		reversed, err := q.GetTransferReversedAmount(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := original.ToAmount - reversed
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount <= 0 || amount > remaining {
+			return fmt.Errorf("%w: %d left of %d", ErrRefundExceedsTransfer, remaining, original.ToAmount)
+		}
+
+		// Refund at the rate of the original transfer so a full reversal
+		// returns exactly what the sender paid.
+		toAmount := amount
+		exchangeRate := "1"
+		if original.Amount != original.ToAmount {
+			toAmount = new(big.Int).Div(
+				new(big.Int).Mul(big.NewInt(amount), big.NewInt(original.Amount)),
+				big.NewInt(original.ToAmount),
+			).Int64()
+			exchangeRate = new(big.Rat).SetFrac64(original.Amount, original.ToAmount).FloatString(10)
+		}
+		if toAmount <= 0 {
+			return ErrRefundAmountTooSmall
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  exchangeRate,
+			RateTimestamp: original.RateTimestamp,
+		}, &original.ID)
+		if err != nil {
+			return err
+		}
+
+		result.RefundedAmount = reversed + amount
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrScheduledTransferNotDue is returned when the occurrence asked for has
+// already been run, the schedule is no longer active, or another runner is
+// working on it.
+var ErrScheduledTransferNotDue = errors.New("scheduled transfer is not due")
+
+// RunScheduledTransferTxParams describes one occurrence of a scheduled
+// transfer. ToAmount, ExchangeRate and RateTimestamp have the same meaning
+// as in TransferTxParams.
+type RunScheduledTransferTxParams struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	ToAmount            int64     `json:"to_amount"`
+	ExchangeRate        string    `json:"exchange_rate"`
+	RateTimestamp       time.Time `json:"rate_timestamp"`
+	// FailureReason records the run as failed without attempting the
+	// transfer, e.g. when no exchange rate was available.
+	FailureReason string `json:"failure_reason"`
+	// NextRunAt is the following occurrence. Leave it invalid to complete
+	// the schedule.
+	NextRunAt sql.NullTime `json:"next_run_at"`
+}
+
+type RunScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRun `json:"run"`
+	// Transfer is nil unless the run succeeded.
+	Transfer *TransferTxResult `json:"transfer"`
+}
+
+// RunScheduledTransferTx runs the occurrence of a schedule due at
+// ScheduledFor. The schedule row is locked and the occurrence must still be
+// its next_run_at, and the unique (scheduled_transfer_id, scheduled_for)
+// index backs that up, so each occurrence moves money at most once even with
+// several runners. A transfer rejected for insufficient funds or an inactive
+// account is rolled back to a savepoint and recorded as a failed run.
+func (store *SQLStore) RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error) {
+	var result RunScheduledTransferTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		schedule, err := q.GetScheduledTransferForUpdate(ctx, arg.ScheduledTransferID)
+		if err == sql.ErrNoRows {
+			return ErrScheduledTransferNotDue
+		}
+		if err != nil {
+			return err
+		}
+
+		if schedule.Status != ScheduledTransferStatusActive ||
+			!schedule.NextRunAt.Valid ||
+			!schedule.NextRunAt.Time.Equal(arg.ScheduledFor) {
+			return ErrScheduledTransferNotDue
+		}
+
+		run := CreateScheduledTransferRunParams{
+			ScheduledTransferID: schedule.ID,
+			ScheduledFor:        arg.ScheduledFor,
+			Status:              ScheduledTransferRunSucceeded,
+		}
+
+		if arg.FailureReason != "" {
+			run.Status = ScheduledTransferRunFailed
+			run.FailureReason = sql.NullString{String: arg.FailureReason, Valid: true}
+		} else {
+			transferResult, err := runScheduledTransfer(ctx, q, schedule, arg)
+			if err != nil && !isScheduledTransferFailure(err) {
+				return err
+			}
+
+			if err != nil {
+				run.Status = ScheduledTransferRunFailed
+				run.FailureReason = sql.NullString{String: err.Error(), Valid: true}
+			} else {
+				result.Transfer = &transferResult
+				run.TransferID = &transferResult.Transfer.ID
+			}
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, run)
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, err = q.UpdateScheduledTransferAfterRun(ctx, UpdateScheduledTransferAfterRunParams{
+			ID:        schedule.ID,
+			NextRunAt: arg.NextRunAt,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// runScheduledTransfer moves the money inside a savepoint so that a
+// rejected transfer can be undone without losing the run record.
+func runScheduledTransfer(ctx context.Context, q *Queries, schedule ScheduledTransfer, arg RunScheduledTransferTxParams) (TransferTxResult, error) {
+	if _, err := q.db.ExecContext(ctx, "SAVEPOINT scheduled_transfer"); err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := transfer(ctx, q, TransferTxParams{
+		FromAccountID: schedule.FromAccountID,
+		ToAccountID:   schedule.ToAccountID,
+		Amount:        schedule.Amount,
+		ToAmount:      arg.ToAmount,
+		ExchangeRate:  arg.ExchangeRate,
+		RateTimestamp: arg.RateTimestamp,
+	}, nil)
+	if err != nil {
+		if _, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT scheduled_transfer"); rbErr != nil {
+			return result, rbErr
+		}
+		return result, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT scheduled_transfer")
+	return result, err
+}
+
+// isScheduledTransferFailure reports whether err is an expected reason for a
+// run to fail, as opposed to a database error that should be retried.
+func isScheduledTransferFailure(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrAccountNotActive)
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, from Account, to Account, amount int64) ScheduledTransfer {
+	start := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+
+	schedule, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:           from.Owner,
+		FromAccountID:   from.ID,
+		ToAccountID:     to.ID,
+		Amount:          amount,
+		Currency:        from.Currency,
+		IntervalSeconds: sql.NullInt64{Int64: 3600, Valid: true},
+		StartAt:         start,
+		NextRunAt:       sql.NullTime{Time: start, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusActive, schedule.Status)
+	require.Zero(t, schedule.RunCount)
+
+	return schedule
+}
+
+func TestRunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createAccountWithBalance(t, 150)
+	account2 := createAccountWithBalance(t, 0)
+	schedule := createRandomScheduledTransfer(t, account1, account2, 100)
+
+	scheduledFor := schedule.NextRunAt.Time
+	nextRunAt := sql.NullTime{Time: scheduledFor.Add(time.Hour), Valid: true}
+
+	result, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        scheduledFor,
+		NextRunAt:           nextRunAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, ScheduledTransferRunSucceeded, result.Run.Status)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, *result.Run.TransferID)
+	require.Equal(t, int64(50), result.Transfer.FromAccount.Balance)
+	require.Equal(t, int64(100), result.Transfer.ToAccount.Balance)
+	require.Equal(t, int32(1), result.ScheduledTransfer.RunCount)
+	require.WithinDuration(t, nextRunAt.Time, result.ScheduledTransfer.NextRunAt.Time, time.Microsecond)
+
+	// the same occurrence never runs twice
+	_, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        scheduledFor,
+		NextRunAt:           nextRunAt,
+	})
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	// insufficient funds is recorded and the schedule moves on
+	result, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        result.ScheduledTransfer.NextRunAt.Time,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, ScheduledTransferRunFailed, result.Run.Status)
+	require.Nil(t, result.Transfer)
+	require.Nil(t, result.Run.TransferID)
+	require.Contains(t, result.Run.FailureReason.String, ErrInsufficientFunds.Error())
+	require.Equal(t, int32(2), result.ScheduledTransfer.RunCount)
+	require.Equal(t, ScheduledTransferStatusCompleted, result.ScheduledTransfer.Status)
+	require.False(t, result.ScheduledTransfer.NextRunAt.Valid)
+
+	account1, err = testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), account1.Balance)
+}
+
+func TestRunScheduledTransferTxPaused(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 0)
+	schedule := createRandomScheduledTransfer(t, account1, account2, 100)
+
+	paused, err := testQueries.UpdateScheduledTransferStatus(context.Background(), UpdateScheduledTransferStatusParams{
+		ID:           schedule.ID,
+		Status:       ScheduledTransferStatusPaused,
+		FromStatuses: []string{ScheduledTransferStatusActive},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusPaused, paused.Status)
+
+	_, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.NextRunAt.Time,
+	})
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	// a schedule can only be paused while it is active
+	_, err = testQueries.UpdateScheduledTransferStatus(context.Background(), UpdateScheduledTransferStatusParams{
+		ID:           schedule.ID,
+		Status:       ScheduledTransferStatusPaused,
+		FromStatuses: []string{ScheduledTransferStatusActive},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
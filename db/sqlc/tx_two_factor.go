@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+)
+
+const (
+	// MaxLoginChallengeAttempts is how many wrong codes a login challenge
+	// takes before it stops accepting any.
+	MaxLoginChallengeAttempts = 5
+	// RecoveryCodeCount is how many recovery codes a user gets when they
+	// enable two-factor authentication.
+	RecoveryCodeCount = 10
+)
+
+var (
+	ErrTOTPNotEnrolled      = errors.New("two-factor authentication is not set up")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorCodeInvalid = errors.New("invalid two-factor code")
+
+	ErrLoginChallengeInvalid = errors.New("invalid login challenge")
+	ErrLoginChallengeUsed    = errors.New("login challenge was already used")
+	ErrLoginChallengeExpired = errors.New("login challenge has expired")
+	ErrLoginChallengeLocked  = errors.New("too many wrong codes for this login challenge")
+)
+
+type EnableTOTPTxParams struct {
+	Username string `json:"username"`
+	Code     string `json:"code"`
+}
+
+type EnableTOTPTxResult struct {
+	Credential TotpCredential `json:"credential"`
+	// RecoveryCodes replace any the user had before. Only their hashes are
+	// stored, so this is the one chance to show them.
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnableTOTPTx turns on two-factor authentication once the user proves their
+// authenticator app works by sending a first code.
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error) {
+	var result EnableTOTPTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		credential, err := q.GetTOTPCredentialForUpdate(ctx, arg.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrTOTPNotEnrolled
+			}
+			return err
+		}
+
+		if credential.IsEnabled {
+			return ErrTOTPAlreadyEnabled
+		}
+
+		step, ok := util.ValidateTOTP(credential.Secret, arg.Code, time.Now())
+		if !ok {
+			return ErrTwoFactorCodeInvalid
+		}
+
+		result.Credential, err = q.EnableTOTPCredential(ctx, EnableTOTPCredentialParams{
+			Username: arg.Username,
+			Step:     step,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.RecoveryCodes = make([]string, RecoveryCodeCount)
+		for i := range result.RecoveryCodes {
+			code, err := util.RandomSecret(10)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: util.HashSecret(code),
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes[i] = code
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+type CompleteLoginChallengeTxParams struct {
+	Token string `json:"token"`
+	// Code is a TOTP code. RecoveryCode is used instead when it is set.
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// CompleteLoginChallengeTx redeems the challenge token of a login that
+// passed the password check, given a second factor. A wrong code counts
+// against the challenge; the attempt is recorded even though the error is
+// returned.
+func (store *SQLStore) CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (User, error) {
+	var user User
+	var codeInvalid bool
+	err := store.execTx(ctx, func(q *Queries) error {
+		challenge, err := q.GetLoginChallengeForUpdate(ctx, util.HashSecret(arg.Token))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrLoginChallengeInvalid
+			}
+			return err
+		}
+
+		if challenge.IsUsed {
+			return ErrLoginChallengeUsed
+		}
+		if time.Now().After(challenge.ExpiredAt) {
+			return ErrLoginChallengeExpired
+		}
+		if challenge.Attempts >= MaxLoginChallengeAttempts {
+			return ErrLoginChallengeLocked
+		}
+
+		valid, err := checkSecondFactor(ctx, q, challenge.Username, arg)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			codeInvalid = true
+			_, err = q.AddLoginChallengeAttempt(ctx, challenge.ID)
+			return err
+		}
+
+		err = q.UseLoginChallenge(ctx, challenge.ID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.GetUser(ctx, challenge.Username)
+		return err
+	})
+	if err == nil && codeInvalid {
+		err = ErrTwoFactorCodeInvalid
+	}
+
+	return user, err
+}
+
+// checkSecondFactor uses up the recovery code or the TOTP time step of arg.
+func checkSecondFactor(ctx context.Context, q *Queries, username string, arg CompleteLoginChallengeTxParams) (bool, error) {
+	if arg.RecoveryCode != "" {
+		_, err := q.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+			Username: username,
+			CodeHash: util.HashSecret(arg.RecoveryCode),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	credential, err := q.GetTOTPCredential(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if !credential.IsEnabled {
+		return false, nil
+	}
+
+	step, ok := util.ValidateTOTP(credential.Secret, arg.Code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// a step at or before the last one used means the code was replayed
+	rows, err := q.UseTOTPStep(ctx, UseTOTPStepParams{
+		Username: username,
+		Step:     step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTOTPCredential(t *testing.T, user User) TotpCredential {
+	secret, err := util.RandomTOTPSecret()
+	require.NoError(t, err)
+
+	credential, err := testQueries.CreateTOTPCredential(context.Background(), CreateTOTPCredentialParams{
+		Username: user.Username,
+		Secret:   secret,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, credential.Username)
+	require.Equal(t, secret, credential.Secret)
+	require.False(t, credential.IsEnabled)
+
+	return credential
+}
+
+// enableRandomTOTP sets up two-factor authentication for user and returns the
+// TOTP secret and recovery codes.
+func enableRandomTOTP(t *testing.T, user User) (string, []string) {
+	store := NewStore(testDB)
+	credential := createRandomTOTPCredential(t, user)
+
+	code, err := util.TOTPCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+
+	result, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username: user.Username,
+		Code:     code,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Credential.IsEnabled)
+	require.Equal(t, util.TOTPStep(time.Now()), result.Credential.LastUsedStep)
+	require.Len(t, result.RecoveryCodes, RecoveryCodeCount)
+
+	return credential.Secret, result.RecoveryCodes
+}
+
+func createRandomLoginChallenge(t *testing.T, user User, expiredAt time.Time) (LoginChallenge, string) {
+	token, err := util.RandomSecret(32)
+	require.NoError(t, err)
+
+	challenge, err := testQueries.CreateLoginChallenge(context.Background(), CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(token),
+		ExpiredAt: expiredAt,
+	})
+	require.NoError(t, err)
+	require.Zero(t, challenge.Attempts)
+	require.False(t, challenge.IsUsed)
+
+	return challenge, token
+}
+
+func TestCreateTOTPCredentialReplacesPending(t *testing.T) {
+	user := createRandomUser(t)
+	first := createRandomTOTPCredential(t, user)
+	second := createRandomTOTPCredential(t, user)
+	require.NotEqual(t, first.Secret, second.Secret)
+
+	enableRandomTOTP(t, user)
+
+	// an enabled secret is never replaced
+	_, err := testQueries.CreateTOTPCredential(context.Background(), CreateTOTPCredentialParams{
+		Username: user.Username,
+		Secret:   "AAAAAAAA",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username: user.Username,
+		Code:     "000000",
+	})
+	require.ErrorIs(t, err, ErrTOTPNotEnrolled)
+
+	credential := createRandomTOTPCredential(t, user)
+	_, err = store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username: user.Username,
+		Code:     "wrong",
+	})
+	require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+
+	code, err := util.TOTPCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+	result, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username: user.Username,
+		Code:     code,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Credential.IsEnabled)
+
+	// enabling twice is refused
+
+	_, err = store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username: user.Username,
+		Code:     code,
+	})
+	require.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+}
+
+func TestCompleteLoginChallengeTxTOTP(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	secret, _ := enableRandomTOTP(t, user)
+	_, token := createRandomLoginChallenge(t, user, time.Now().Add(time.Minute))
+
+	// the code used to enable 2FA can't be replayed
+	code, err := util.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: token,
+		Code:  code,
+	})
+	require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+
+	code, err = util.TOTPCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	loggedIn, err := store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: token,
+		Code:  code,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, loggedIn.Username)
+
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: token,
+		Code:  code,
+	})
+	require.ErrorIs(t, err, ErrLoginChallengeUsed)
+}
+
+func TestCompleteLoginChallengeTxRecoveryCode(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, recoveryCodes := enableRandomTOTP(t, user)
+
+	_, token := createRandomLoginChallenge(t, user, time.Now().Add(time.Minute))
+	loggedIn, err := store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token:        token,
+		RecoveryCode: recoveryCodes[0],
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, loggedIn.Username)
+
+	// each recovery code works once
+	_, token = createRandomLoginChallenge(t, user, time.Now().Add(time.Minute))
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token:        token,
+		RecoveryCode: recoveryCodes[0],
+	})
+	require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token:        token,
+		RecoveryCode: recoveryCodes[1],
+	})
+	require.NoError(t, err)
+}
+
+func TestCompleteLoginChallengeTxLocked(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	secret, _ := enableRandomTOTP(t, user)
+	_, token := createRandomLoginChallenge(t, user, time.Now().Add(time.Minute))
+
+	for i := 0; i < MaxLoginChallengeAttempts; i++ {
+		_, err := store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+			Token: token,
+			Code:  "000000",
+		})
+		require.Error(t, err)
+	}
+
+	// even the right code is refused now
+	code, err := util.TOTPCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: token,
+		Code:  code,
+	})
+	require.ErrorIs(t, err, ErrLoginChallengeLocked)
+}
+
+func TestCompleteLoginChallengeTxInvalid(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: util.RandomString(32),
+		Code:  "000000",
+	})
+	require.ErrorIs(t, err, ErrLoginChallengeInvalid)
+
+	_, token := createRandomLoginChallenge(t, user, time.Now().Add(-time.Minute))
+	_, err = store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+		Token: token,
+		Code:  "000000",
+	})
+	require.ErrorIs(t, err, ErrLoginChallengeExpired)
+}
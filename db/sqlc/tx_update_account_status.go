@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Account statuses. Only active accounts can send or receive money.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+var (
+	ErrAccountNotActive        = errors.New("account is not active")
+	ErrAccountBalanceNotZero   = errors.New("account balance must be zero to close it")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+)
+
+// accountStatusTransitions lists the statuses each status may move to.
+// Closed is terminal.
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive, AccountStatusClosed},
+}
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+// UpdateAccountStatusTx moves an account to a new status. The account row is
+// locked first so that a concurrent transfer cannot change the balance
+// between the zero-balance check and the close.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !canTransitionAccountStatus(account.Status, arg.Status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, account.Status, arg.Status)
+		}
+
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			return ErrAccountBalanceNotZero
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+func canTransitionAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UpdateUserTxParams struct {
+	Username       string         `json:"username"`
+	FullName       sql.NullString `json:"full_name"`
+	Email          sql.NullString `json:"email"`
+	HashedPassword sql.NullString `json:"hashed_password"`
+	// AfterUpdate runs inside the transaction with the updated user, so that
+	// follow-up work such as enqueueing jobs commits together with it.
+	AfterUpdate func(q Querier, user User) error
+}
+
+// UpdateUserTx updates the fields of a user that are set in arg. A password
+// change also blocks every session of the user and uses up their password
+// reset tokens, so that only the new password gets them back in.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username:       arg.Username,
+			FullName:       arg.FullName,
+			Email:          arg.Email,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.HashedPassword.Valid {
+			err = q.BlockUserSessions(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+
+			err = q.UseUserPasswordResetTokens(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.AfterUpdate == nil {
+			return nil
+		}
+		return arg.AfterUpdate(q, user)
+	})
+
+	return user, err
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserTxFullName(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	newFullName := util.RandOwner()
+
+	updatedUser, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user.Username,
+		FullName: sql.NullString{String: newFullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, user.Email, updatedUser.Email)
+	require.Equal(t, user.HashedPassword, updatedUser.HashedPassword)
+	require.Equal(t, user.IsEmailVerified, updatedUser.IsEmailVerified)
+	require.WithinDuration(t, user.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Second)
+}
+
+func TestUpdateUserTxEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail, secretCode := createRandomVerifyEmail(t, user, time.Now().Add(time.Hour))
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: secretCode,
+	})
+	require.NoError(t, err)
+
+	// keeping the same address keeps it verified
+	updatedUser, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user.Username,
+		Email:    sql.NullString{String: user.Email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, updatedUser.IsEmailVerified)
+
+	newEmail := util.RandomEmail()
+	updatedUser, err = store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user.Username,
+		Email:    sql.NullString{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.False(t, updatedUser.IsEmailVerified)
+
+	// codes sent to the old address no longer verify the account
+	verifyEmail, secretCode = createRandomVerifyEmail(t, user, time.Now().Add(time.Hour))
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: secretCode,
+	})
+	require.ErrorIs(t, err, ErrVerifyEmailInvalid)
+}
+
+func TestUpdateUserTxPassword(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Hour))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	updatedUser, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username:       user.Username,
+		HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updatedUser.HashedPassword)
+	require.True(t, updatedUser.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	changedAt, err := store.GetUserPasswordChangedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, updatedUser.PasswordChangedAt, changedAt, time.Millisecond)
+
+	session, err = store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		Token:          resetToken,
+		HashedPassword: hashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+)
+
+var (
+	ErrVerifyEmailInvalid = errors.New("invalid email verification code")
+	ErrVerifyEmailUsed    = errors.New("email verification code was already used")
+	ErrVerifyEmailExpired = errors.New("email verification code has expired")
+)
+
+type VerifyEmailTxParams struct {
+	EmailID    int64  `json:"email_id"`
+	SecretCode string `json:"secret_code"`
+}
+
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx uses up a verification code and marks the email it was sent
+// to as verified. A code only verifies the address it was mailed to, so it
+// stops working once the user changes their email.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		verifyEmail, err := q.GetVerifyEmailForUpdate(ctx, arg.EmailID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrVerifyEmailInvalid
+			}
+			return err
+		}
+
+		// check the code first so that nothing about the row leaks to
+		// someone who does not hold it
+		if !util.CheckSecret(arg.SecretCode, verifyEmail.SecretCodeHash) {
+			return ErrVerifyEmailInvalid
+		}
+		if verifyEmail.IsUsed {
+			return ErrVerifyEmailUsed
+		}
+		if time.Now().After(verifyEmail.ExpiredAt) {
+			return ErrVerifyEmailExpired
+		}
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, verifyEmail.ID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: verifyEmail.Username,
+			Email:    verifyEmail.Email,
+		})
+		if err == sql.ErrNoRows {
+			return ErrVerifyEmailInvalid
+		}
+		return err
+	})
+
+	return result, err
+}
@@ -29,6 +29,7 @@ func createRandomUser(t *testing.T) User {
     require.Equal(t, arg.Email, user.Email)
     require.NotZero(t, user.CreatedAt)
     require.True(t, user.PasswordChangedAt.IsZero())
+    require.Equal(t, util.DepositorRole, user.Role)
 
     return user
 }
@@ -49,4 +50,22 @@ func TestGetUser(t *testing.T) {
     require.Equal(t, user1.Email, user2.Email)
     require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
     require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
-}
\ No newline at end of file
+}
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+
+	updatedUser, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     util.BankerRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updatedUser.Username)
+	require.Equal(t, util.BankerRole, updatedUser.Role)
+
+	// roles outside the known set are rejected by the database
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     "superuser",
+	})
+	require.Error(t, err)
+}
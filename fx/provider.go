@@ -0,0 +1,98 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+)
+
+// ErrRateNotFound is returned when no exchange rate is known for a currency pair.
+var ErrRateNotFound = errors.New("exchange rate not available")
+
+// Quote is the exchange rate used to convert an amount from one currency to another.
+type Quote struct {
+	From      string
+	To        string
+	Rate      string // decimal, units of To per unit of From
+	Timestamp time.Time
+}
+
+// Convert returns amount converted at the quoted rate.
+// The result is rounded down to the nearest minor unit.
+func (quote Quote) Convert(amount int64) (int64, error) {
+	rate, ok := new(big.Rat).SetString(quote.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate %q", quote.Rate)
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows")
+	}
+	return result.Int64(), nil
+}
+
+// FXRateProvider looks up the rate at which to convert between two currencies.
+type FXRateProvider interface {
+	GetQuote(ctx context.Context, from string, to string) (Quote, error)
+}
+
+// DBRateProvider reads exchange rates from the fx_rates table.
+type DBRateProvider struct {
+	store db.Querier
+}
+
+// NewDBRateProvider creates a provider backed by the fx_rates table.
+func NewDBRateProvider(store db.Querier) FXRateProvider {
+	return &DBRateProvider{store: store}
+}
+
+// GetQuote returns the stored rate for from->to. If only the opposite
+// direction is stored, its inverse is used instead.
+func (provider *DBRateProvider) GetQuote(ctx context.Context, from string, to string) (Quote, error) {
+	if from == to {
+		return Quote{From: from, To: to, Rate: "1", Timestamp: time.Now()}, nil
+	}
+
+	rate, err := provider.store.GetFXRate(ctx, db.GetFXRateParams{
+		BaseCurrency:  from,
+		QuoteCurrency: to,
+	})
+	if err == nil {
+		return Quote{From: from, To: to, Rate: rate.Rate, Timestamp: rate.UpdatedAt}, nil
+	}
+	if err != sql.ErrNoRows {
+		return Quote{}, err
+	}
+
+	rate, err = provider.store.GetFXRate(ctx, db.GetFXRateParams{
+		BaseCurrency:  to,
+		QuoteCurrency: from,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Quote{}, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+		}
+		return Quote{}, err
+	}
+
+	inverse, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || inverse.Sign() <= 0 {
+		return Quote{}, fmt.Errorf("invalid exchange rate %q", rate.Rate)
+	}
+	inverse.Inv(inverse)
+
+	quote := Quote{
+		From:      from,
+		To:        to,
+		Rate:      inverse.FloatString(10),
+		Timestamp: rate.UpdatedAt,
+	}
+	return quote, nil
+}
@@ -0,0 +1,86 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteConvert(t *testing.T) {
+	testCases := []struct {
+		rate     string
+		amount   int64
+		expected int64
+	}{
+		{rate: "1", amount: 100, expected: 100},
+		{rate: "0.9", amount: 100, expected: 90},
+		{rate: "7.1234", amount: 1000, expected: 7123},
+		{rate: "0.333333", amount: 10, expected: 3},
+	}
+
+	for _, tc := range testCases {
+		quote := Quote{Rate: tc.rate}
+		converted, err := quote.Convert(tc.amount)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted)
+	}
+
+	_, err := Quote{Rate: "abc"}.Convert(100)
+	require.Error(t, err)
+
+	_, err = Quote{Rate: "-1"}.Convert(100)
+	require.Error(t, err)
+}
+
+func TestDBRateProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	provider := NewDBRateProvider(store)
+
+	updatedAt := time.Now()
+
+	// same currency never hits the database
+	quote, err := provider.GetQuote(context.Background(), "USD", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1", quote.Rate)
+
+	// direct rate
+	store.EXPECT().
+		GetFXRate(gomock.Any(), gomock.Eq(db.GetFXRateParams{BaseCurrency: "USD", QuoteCurrency: "EUR"})).
+		Times(1).
+		Return(db.FxRate{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "0.9", UpdatedAt: updatedAt}, nil)
+	quote, err = provider.GetQuote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.9", quote.Rate)
+	require.Equal(t, updatedAt, quote.Timestamp)
+
+	// inverse rate
+	gomock.InOrder(
+		store.EXPECT().
+			GetFXRate(gomock.Any(), gomock.Eq(db.GetFXRateParams{BaseCurrency: "EUR", QuoteCurrency: "USD"})).
+			Times(1).
+			Return(db.FxRate{}, sql.ErrNoRows),
+		store.EXPECT().
+			GetFXRate(gomock.Any(), gomock.Eq(db.GetFXRateParams{BaseCurrency: "USD", QuoteCurrency: "EUR"})).
+			Times(1).
+			Return(db.FxRate{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "0.8", UpdatedAt: updatedAt}, nil),
+	)
+	quote, err = provider.GetQuote(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.2500000000", quote.Rate)
+
+	// no rate either way
+	store.EXPECT().
+		GetFXRate(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.FxRate{}, sql.ErrNoRows)
+	_, err = provider.GetQuote(context.Background(), "CAD", "RMB")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// updateAccountStatus backs the freeze, unfreeze and close RPCs.
+func (server *Server) updateAccountStatus(ctx context.Context, accountID int64, accountStatus string) (db.Account, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	if accountID < 1 {
+		return db.Account{}, status.Errorf(codes.InvalidArgument, "invalid account id: %d", accountID)
+	}
+
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Errorf(codes.NotFound, "account not found")
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	action, owner := policy.AccountStatusAction(accountStatus, account.Owner)
+	if err := policy.Authorize(authPayload, action, owner); err != nil {
+		return account, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	account, err = server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: accountID,
+		Status:    accountStatus,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidStatusTransition) || errors.Is(err, db.ErrAccountBalanceNotZero) {
+			return account, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return account, status.Errorf(codes.Internal, "failed to update account status: %s", err)
+	}
+
+	return account, nil
+}
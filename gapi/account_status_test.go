@@ -0,0 +1,201 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateAccountStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	freeze := func(server *Server, ctx context.Context, id int64) (*pb.Account, error) {
+		res, err := server.FreezeAccount(ctx, &pb.FreezeAccountRequest{Id: id})
+		return res.GetAccount(), err
+	}
+	unfreeze := func(server *Server, ctx context.Context, id int64) (*pb.Account, error) {
+		res, err := server.UnfreezeAccount(ctx, &pb.UnfreezeAccountRequest{Id: id})
+		return res.GetAccount(), err
+	}
+	closeAccount := func(server *Server, ctx context.Context, id int64) (*pb.Account, error) {
+		res, err := server.CloseAccount(ctx, &pb.CloseAccountRequest{Id: id})
+		return res.GetAccount(), err
+	}
+
+	updated := func(accountStatus string) func(store *mockdb.MockStore) {
+		return func(store *mockdb.MockStore) {
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			arg := db.UpdateAccountStatusTxParams{
+				AccountID: account.ID,
+				Status:    accountStatus,
+			}
+			result := account
+			result.Status = accountStatus
+			store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+		}
+	}
+	denied := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+	}
+
+	testCases := []struct {
+		name          string
+		call          func(server *Server, ctx context.Context, id int64) (*pb.Account, error)
+		id            int64
+		role          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.Account, err error)
+	}{
+		{
+			name:       "OwnerFreezes",
+			call:       freeze,
+			id:         account.ID,
+			username:   user.Username,
+			role:       util.DepositorRole,
+			buildStubs: updated(db.AccountStatusFrozen),
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.AccountStatusFrozen, res.GetStatus())
+			},
+		},
+		{
+			name:       "OwnerCannotUnfreeze",
+			call:       unfreeze,
+			id:         account.ID,
+			username:   user.Username,
+			role:       util.DepositorRole,
+			buildStubs: denied,
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:       "BankerUnfreezes",
+			call:       unfreeze,
+			id:         account.ID,
+			username:   "banker",
+			role:       util.BankerRole,
+			buildStubs: updated(db.AccountStatusActive),
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.AccountStatusActive, res.GetStatus())
+			},
+		},
+		{
+			name:       "OwnerCloses",
+			call:       closeAccount,
+			id:         account.ID,
+			username:   user.Username,
+			role:       util.DepositorRole,
+			buildStubs: updated(db.AccountStatusClosed),
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.AccountStatusClosed, res.GetStatus())
+			},
+		},
+		{
+			name:       "BankerCannotClose",
+			call:       closeAccount,
+			id:         account.ID,
+			username:   "banker",
+			role:       util.BankerRole,
+			buildStubs: denied,
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:       "OthersAccount",
+			call:       freeze,
+			id:         account.ID,
+			username:   "other",
+			role:       util.DepositorRole,
+			buildStubs: denied,
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "BalanceNotZero",
+			call:     closeAccount,
+			id:       account.ID,
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "InvalidTransition",
+			call:     freeze,
+			id:       account.ID,
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "NotFound",
+			call:     freeze,
+			id:       account.ID,
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:     "InvalidID",
+			call:     closeAccount,
+			id:       0,
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.Account, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newAuthContext(t, tc.username, tc.role)
+			res, err := tc.call(server, ctx, tc.id)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/HzTTT/simple_bank/apikey"
+	"github.com/HzTTT/simple_bank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+)
+
+type authPayloadKey struct{}
+
+// authorizeUser verifies the bearer token or API key carried in the incoming
+// metadata. The gateway forwards the HTTP Authorization header under the same
+// key, so this works for both transports. Failing to look up an API key is
+// returned as an Internal status.
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationBearer {
+		return nil, fmt.Errorf("unsupported authorization type: %s", authType)
+	}
+
+	accessToken := fields[1]
+	if apikey.IsAPIKey(accessToken) {
+		payload, err := apikey.Verify(ctx, server.store, accessToken)
+		if err != nil {
+			if !errors.Is(err, apikey.ErrInvalidKey) {
+				return nil, status.Errorf(codes.Internal, "%s", err)
+			}
+			return nil, err
+		}
+		return payload, nil
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
+	}
+
+	return payload, nil
+}
+
+// contextWithAuthPayload returns a copy of ctx carrying the verified payload.
+func contextWithAuthPayload(ctx context.Context, payload *token.Payload) context.Context {
+	return context.WithValue(ctx, authPayloadKey{}, payload)
+}
+
+// authPayloadFromContext returns the payload stored by the auth interceptor.
+// Handlers of authenticated RPCs call it instead of reading metadata themselves.
+func authPayloadFromContext(ctx context.Context) (*token.Payload, error) {
+	payload, ok := ctx.Value(authPayloadKey{}).(*token.Payload)
+	if !ok || payload == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: missing authorization payload")
+	}
+	return payload, nil
+}
@@ -13,5 +13,121 @@ func convertUser(user db.User) *pb.User {
 		Email:             user.Email,
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 		CreatedAt:         timestamppb.New(user.CreatedAt),
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
 	}
-}
\ No newline at end of file
+}
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:             account.ID,
+		Owner:          account.Owner,
+		Balance:        account.Balance,
+		Currency:       account.Currency,
+		CreatedAt:      timestamppb.New(account.CreatedAt),
+		OverdraftLimit: account.OverdraftLimit,
+		Status:         account.Status,
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	rsp := &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+	if entry.TransferID != nil {
+		rsp.TransferId = *entry.TransferID
+	}
+	return rsp
+}
+
+func convertAccountEntry(row db.ListAccountEntriesRow) *pb.AccountEntry {
+	rsp := &pb.AccountEntry{
+		Entry: convertEntry(db.Entry{
+			ID:         row.ID,
+			AccountID:  row.AccountID,
+			Amount:     row.Amount,
+			CreatedAt:  row.CreatedAt,
+			TransferID: row.TransferID,
+		}),
+	}
+	if counterparty := row.CounterpartyAccountID(); counterparty != nil {
+		rsp.CounterpartyAccountId = *counterparty
+	}
+	return rsp
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	rsp := &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+		ToAmount:      transfer.ToAmount,
+		ExchangeRate:  transfer.ExchangeRate,
+		RateTimestamp: timestamppb.New(transfer.RateTimestamp),
+	}
+	if transfer.ReversalOfID != nil {
+		rsp.ReversalOfId = *transfer.ReversalOfID
+	}
+	return rsp
+}
+
+func convertScheduledTransfer(schedule db.ScheduledTransfer) *pb.ScheduledTransfer {
+	rsp := &pb.ScheduledTransfer{
+		Id:              schedule.ID,
+		Owner:           schedule.Owner,
+		FromAccountId:   schedule.FromAccountID,
+		ToAccountId:     schedule.ToAccountID,
+		Amount:          schedule.Amount,
+		Currency:        schedule.Currency,
+		CronExpression:  schedule.CronExpression.String,
+		IntervalSeconds: schedule.IntervalSeconds.Int64,
+		StartAt:         timestamppb.New(schedule.StartAt),
+		MaxRuns:         schedule.MaxRuns.Int32,
+		RunCount:        schedule.RunCount,
+		Status:          schedule.Status,
+		CreatedAt:       timestamppb.New(schedule.CreatedAt),
+	}
+	if schedule.EndAt.Valid {
+		rsp.EndAt = timestamppb.New(schedule.EndAt.Time)
+	}
+	if schedule.NextRunAt.Valid {
+		rsp.NextRunAt = timestamppb.New(schedule.NextRunAt.Time)
+	}
+	return rsp
+}
+
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID.String(),
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}
+}
+
+func convertAPIKey(apiKey db.ApiKey) *pb.APIKey {
+	return &pb.APIKey{
+		Id:        apiKey.ID.String(),
+		Name:      apiKey.Name,
+		Scopes:    apiKey.Scopes,
+		IsRevoked: apiKey.IsRevoked,
+		ExpiresAt: timestamppb.New(apiKey.ExpiresAt),
+		CreatedAt: timestamppb.New(apiKey.CreatedAt),
+	}
+}
+
+func convertOAuthClient(client db.OauthClient) *pb.OAuthClient {
+	return &pb.OAuthClient{
+		ClientId:   client.ClientID,
+		Name:       client.Name,
+		Username:   client.Username,
+		Scopes:     client.Scopes,
+		IsDisabled: client.IsDisabled,
+		CreatedAt:  timestamppb.New(client.CreatedAt),
+	}
+}
@@ -0,0 +1,217 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GatewayServer adapts Server for pb.RegisterSimpleBankHandlerServer.
+// The gateway calls the service methods directly and skips the gRPC server,
+// so every method is routed through the same unary interceptor here.
+type GatewayServer struct {
+	pb.UnimplementedSimpleBankServer
+	server *Server
+}
+
+// NewGatewayServer wraps server for use with the grpc-gateway.
+func NewGatewayServer(server *Server) *GatewayServer {
+	return &GatewayServer{server: server}
+}
+
+// GatewayErrorHandler writes errors like runtime.DefaultHTTPErrorHandler,
+// adding a Retry-After header to ResourceExhausted errors that carry a
+// RetryInfo, so HTTP clients learn when to retry as gRPC clients do.
+func GatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(info.GetRetryDelay().AsDuration().Seconds())))
+			}
+		}
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+func invoke[Req any, Rsp any](
+	ctx context.Context,
+	gateway *GatewayServer,
+	fullMethod string,
+	req Req,
+	method func(context.Context, Req) (Rsp, error),
+) (Rsp, error) {
+	info := &grpc.UnaryServerInfo{
+		Server:     gateway.server,
+		FullMethod: fullMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return method(ctx, req.(Req))
+	}
+
+	var rsp Rsp
+	ctx = contextWithGatewayRequest(ctx)
+	result, err := gateway.server.UnaryAuthInterceptor(ctx, req, info, handler)
+	if err != nil {
+		return rsp, err
+	}
+	return result.(Rsp), nil
+}
+
+func (gateway *GatewayServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateUser_FullMethodName, req, gateway.server.CreateUser)
+}
+
+func (gateway *GatewayServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_LoginUser_FullMethodName, req, gateway.server.LoginUser)
+}
+
+func (gateway *GatewayServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateAccount_FullMethodName, req, gateway.server.CreateAccount)
+}
+
+func (gateway *GatewayServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_GetAccount_FullMethodName, req, gateway.server.GetAccount)
+}
+
+func (gateway *GatewayServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListAccounts_FullMethodName, req, gateway.server.ListAccounts)
+}
+
+func (gateway *GatewayServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateTransfer_FullMethodName, req, gateway.server.CreateTransfer)
+}
+
+func (gateway *GatewayServer) FreezeAccount(ctx context.Context, req *pb.FreezeAccountRequest) (*pb.FreezeAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_FreezeAccount_FullMethodName, req, gateway.server.FreezeAccount)
+}
+
+func (gateway *GatewayServer) UnfreezeAccount(ctx context.Context, req *pb.UnfreezeAccountRequest) (*pb.UnfreezeAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_UnfreezeAccount_FullMethodName, req, gateway.server.UnfreezeAccount)
+}
+
+func (gateway *GatewayServer) CloseAccount(ctx context.Context, req *pb.CloseAccountRequest) (*pb.CloseAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CloseAccount_FullMethodName, req, gateway.server.CloseAccount)
+}
+
+func (gateway *GatewayServer) ListAccountEntries(ctx context.Context, req *pb.ListAccountEntriesRequest) (*pb.ListAccountEntriesResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListAccountEntries_FullMethodName, req, gateway.server.ListAccountEntries)
+}
+
+func (gateway *GatewayServer) ListAccountTransfers(ctx context.Context, req *pb.ListAccountTransfersRequest) (*pb.ListAccountTransfersResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListAccountTransfers_FullMethodName, req, gateway.server.ListAccountTransfers)
+}
+
+func (gateway *GatewayServer) ReverseTransfer(ctx context.Context, req *pb.ReverseTransferRequest) (*pb.ReverseTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ReverseTransfer_FullMethodName, req, gateway.server.ReverseTransfer)
+}
+
+func (gateway *GatewayServer) CreateScheduledTransfer(ctx context.Context, req *pb.CreateScheduledTransferRequest) (*pb.CreateScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateScheduledTransfer_FullMethodName, req, gateway.server.CreateScheduledTransfer)
+}
+
+func (gateway *GatewayServer) ListScheduledTransfers(ctx context.Context, req *pb.ListScheduledTransfersRequest) (*pb.ListScheduledTransfersResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListScheduledTransfers_FullMethodName, req, gateway.server.ListScheduledTransfers)
+}
+
+func (gateway *GatewayServer) PauseScheduledTransfer(ctx context.Context, req *pb.PauseScheduledTransferRequest) (*pb.PauseScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_PauseScheduledTransfer_FullMethodName, req, gateway.server.PauseScheduledTransfer)
+}
+
+func (gateway *GatewayServer) ResumeScheduledTransfer(ctx context.Context, req *pb.ResumeScheduledTransferRequest) (*pb.ResumeScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ResumeScheduledTransfer_FullMethodName, req, gateway.server.ResumeScheduledTransfer)
+}
+
+func (gateway *GatewayServer) CancelScheduledTransfer(ctx context.Context, req *pb.CancelScheduledTransferRequest) (*pb.CancelScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CancelScheduledTransfer_FullMethodName, req, gateway.server.CancelScheduledTransfer)
+}
+
+func (gateway *GatewayServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_VerifyEmail_FullMethodName, req, gateway.server.VerifyEmail)
+}
+
+func (gateway *GatewayServer) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_RequestPasswordReset_FullMethodName, req, gateway.server.RequestPasswordReset)
+}
+
+func (gateway *GatewayServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ResetPassword_FullMethodName, req, gateway.server.ResetPassword)
+}
+
+func (gateway *GatewayServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_UpdateUser_FullMethodName, req, gateway.server.UpdateUser)
+}
+
+func (gateway *GatewayServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ChangePassword_FullMethodName, req, gateway.server.ChangePassword)
+}
+
+func (gateway *GatewayServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListSessions_FullMethodName, req, gateway.server.ListSessions)
+}
+
+func (gateway *GatewayServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_RevokeSession_FullMethodName, req, gateway.server.RevokeSession)
+}
+
+func (gateway *GatewayServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_Logout_FullMethodName, req, gateway.server.Logout)
+}
+
+func (gateway *GatewayServer) LogoutAllDevices(ctx context.Context, req *pb.LogoutAllDevicesRequest) (*pb.LogoutAllDevicesResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_LogoutAllDevices_FullMethodName, req, gateway.server.LogoutAllDevices)
+}
+
+func (gateway *GatewayServer) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_RenewAccessToken_FullMethodName, req, gateway.server.RenewAccessToken)
+}
+
+func (gateway *GatewayServer) UpdateUserRole(ctx context.Context, req *pb.UpdateUserRoleRequest) (*pb.UpdateUserRoleResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_UpdateUserRole_FullMethodName, req, gateway.server.UpdateUserRole)
+}
+
+func (gateway *GatewayServer) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_EnrollTOTP_FullMethodName, req, gateway.server.EnrollTOTP)
+}
+
+func (gateway *GatewayServer) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ConfirmTOTP_FullMethodName, req, gateway.server.ConfirmTOTP)
+}
+
+func (gateway *GatewayServer) LoginUserTOTP(ctx context.Context, req *pb.LoginUserTOTPRequest) (*pb.LoginUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_LoginUserTOTP_FullMethodName, req, gateway.server.LoginUserTOTP)
+}
+
+func (gateway *GatewayServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateAPIKey_FullMethodName, req, gateway.server.CreateAPIKey)
+}
+
+func (gateway *GatewayServer) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListAPIKeys_FullMethodName, req, gateway.server.ListAPIKeys)
+}
+
+func (gateway *GatewayServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_RevokeAPIKey_FullMethodName, req, gateway.server.RevokeAPIKey)
+}
+
+func (gateway *GatewayServer) CreateOAuthClient(ctx context.Context, req *pb.CreateOAuthClientRequest) (*pb.CreateOAuthClientResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_CreateOAuthClient_FullMethodName, req, gateway.server.CreateOAuthClient)
+}
+
+func (gateway *GatewayServer) ListOAuthClients(ctx context.Context, req *pb.ListOAuthClientsRequest) (*pb.ListOAuthClientsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_ListOAuthClients_FullMethodName, req, gateway.server.ListOAuthClients)
+}
+
+func (gateway *GatewayServer) DisableOAuthClient(ctx context.Context, req *pb.DisableOAuthClientRequest) (*pb.DisableOAuthClientResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_DisableOAuthClient_FullMethodName, req, gateway.server.DisableOAuthClient)
+}
+
+func (gateway *GatewayServer) UpdateOverdraftLimit(ctx context.Context, req *pb.UpdateOverdraftLimitRequest) (*pb.UpdateOverdraftLimitResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBank_UpdateOverdraftLimit_FullMethodName, req, gateway.server.UpdateOverdraftLimit)
+}
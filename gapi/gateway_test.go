@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/throttle"
+	"github.com/golang/mock/gomock"
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestGatewayServerAuthenticates calls every RPC of the service through the
+// gateway without a token. Those that aren't public must be stopped by the
+// interceptor, and none may fall through to the unimplemented stub.
+func TestGatewayServerAuthenticates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gateway := reflect.ValueOf(NewGatewayServer(newTestServer(t, store)))
+	service := reflect.TypeOf((*pb.SimpleBankServer)(nil)).Elem()
+	for i := 0; i < service.NumMethod(); i++ {
+		method := service.Method(i)
+		if method.Name == "mustEmbedUnimplementedSimpleBankServer" {
+			continue
+		}
+		fullMethod := "/" + pb.SimpleBank_ServiceDesc.ServiceName + "/" + method.Name
+
+		t.Run(method.Name, func(t *testing.T) {
+			if publicMethods[fullMethod] {
+				t.Skip("public methods reach their handler")
+			}
+
+			req := reflect.New(method.Type.In(1).Elem())
+			results := gateway.MethodByName(method.Name).Call([]reflect.Value{
+				reflect.ValueOf(context.Background()),
+				req,
+			})
+
+			err, _ := results[1].Interface().(error)
+			require.Equal(t, codes.Unauthenticated, status.Code(err), "%s", err)
+		})
+	}
+}
+
+// TestGatewayServerPublicMethods makes sure the public RPCs are wrapped by
+// the gateway too, rather than promoted from the unimplemented stub.
+func TestGatewayServerPublicMethods(t *testing.T) {
+	gateway := reflect.TypeOf(&GatewayServer{})
+	for fullMethod := range publicMethods {
+		name := path.Base(fullMethod)
+		method, ok := gateway.MethodByName(name)
+		require.True(t, ok, name)
+
+		fn := runtime.FuncForPC(method.Func.Pointer())
+		file, _ := fn.FileLine(fn.Entry())
+		require.Equal(t, "gateway.go", filepath.Base(file), name)
+	}
+}
+
+func TestGatewayErrorHandlerRetryAfter(t *testing.T) {
+	mux := gwruntime.NewServeMux()
+	request := httptest.NewRequest(http.MethodPost, "/v1/login_user", nil)
+
+	recorder := httptest.NewRecorder()
+	err := throttledError(&throttle.Error{RetryAfter: 30 * time.Second})
+	GatewayErrorHandler(context.Background(), mux, &gwruntime.JSONPb{}, recorder, request, err)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+	recorder = httptest.NewRecorder()
+	err = status.Errorf(codes.Unauthenticated, "incorrect username or password")
+	GatewayErrorHandler(context.Background(), mux, &gwruntime.JSONPb{}, recorder, request, err)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Retry-After"))
+}
@@ -0,0 +1,148 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods lists the RPCs that can be called without an access token.
+// Every other method requires a valid bearer token.
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName:           true,
+	pb.SimpleBank_LoginUser_FullMethodName:            true,
+	pb.SimpleBank_VerifyEmail_FullMethodName:          true,
+	pb.SimpleBank_RequestPasswordReset_FullMethodName: true,
+	pb.SimpleBank_ResetPassword_FullMethodName:        true,
+	pb.SimpleBank_Logout_FullMethodName:               true,
+	pb.SimpleBank_RenewAccessToken_FullMethodName:     true,
+	pb.SimpleBank_LoginUserTOTP_FullMethodName:        true,
+}
+
+// methodActions lists the RPCs that only some roles may call, whoever the
+// resource belongs to.
+var methodActions = map[string]policy.Action{
+	pb.SimpleBank_UpdateUserRole_FullMethodName:       policy.ManageRoles,
+	pb.SimpleBank_UpdateOverdraftLimit_FullMethodName: policy.SetOverdraftLimit,
+	pb.SimpleBank_CreateOAuthClient_FullMethodName:    policy.ManageOAuthClients,
+	pb.SimpleBank_ListOAuthClients_FullMethodName:     policy.ManageOAuthClients,
+	pb.SimpleBank_DisableOAuthClient_FullMethodName:   policy.ManageOAuthClients,
+}
+
+// methodScopes lists the scope that API keys need for each RPC. RPCs missing
+// here only accept access tokens.
+var methodScopes = map[string]policy.Scope{
+	pb.SimpleBank_GetAccount_FullMethodName:              policy.ScopeAccountsRead,
+	pb.SimpleBank_ListAccounts_FullMethodName:            policy.ScopeAccountsRead,
+	pb.SimpleBank_ListAccountEntries_FullMethodName:      policy.ScopeAccountsRead,
+	pb.SimpleBank_CreateAccount_FullMethodName:           policy.ScopeAccountsWrite,
+	pb.SimpleBank_FreezeAccount_FullMethodName:           policy.ScopeAccountsWrite,
+	pb.SimpleBank_UnfreezeAccount_FullMethodName:         policy.ScopeAccountsWrite,
+	pb.SimpleBank_CloseAccount_FullMethodName:            policy.ScopeAccountsWrite,
+	pb.SimpleBank_ListAccountTransfers_FullMethodName:    policy.ScopeTransfersRead,
+	pb.SimpleBank_ListScheduledTransfers_FullMethodName:  policy.ScopeTransfersRead,
+	pb.SimpleBank_CreateTransfer_FullMethodName:          policy.ScopeTransfersWrite,
+	pb.SimpleBank_ReverseTransfer_FullMethodName:         policy.ScopeTransfersWrite,
+	pb.SimpleBank_CreateScheduledTransfer_FullMethodName: policy.ScopeTransfersWrite,
+	pb.SimpleBank_PauseScheduledTransfer_FullMethodName:  policy.ScopeTransfersWrite,
+	pb.SimpleBank_ResumeScheduledTransfer_FullMethodName: policy.ScopeTransfersWrite,
+	pb.SimpleBank_CancelScheduledTransfer_FullMethodName: policy.ScopeTransfersWrite,
+}
+
+// authenticate verifies the caller of fullMethod and returns a context that
+// carries the token payload. Tokens issued before the user last changed their
+// password or to a disabled OAuth client are rejected, as are callers whose
+// role doesn't allow fullMethod and API keys lacking its scope.
+// Public methods pass through untouched.
+func (server *Server) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if publicMethods[fullMethod] {
+		return ctx, nil
+	}
+
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+	}
+
+	passwordChangedAt, err := server.store.GetUserPasswordChangedAt(ctx, payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.Unauthenticated, "unauthorized: user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+	if payload.IssuedBefore(passwordChangedAt) {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %s", token.ErrPasswordChanged)
+	}
+
+	if payload.ClientID != "" {
+		if err := oauth.CheckClient(ctx, server.store, payload.ClientID); err != nil {
+			if errors.Is(err, oauth.ErrClientDisabled) {
+				return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+			}
+			return nil, status.Errorf(codes.Internal, "%s", err)
+		}
+	}
+
+	if err := policy.RequireScope(payload, methodScopes[fullMethod]); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	if action, ok := methodActions[fullMethod]; ok {
+		if err := policy.Authorize(payload, action, ""); err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+		}
+	}
+
+	return contextWithAuthPayload(ctx, payload), nil
+}
+
+// UnaryAuthInterceptor authenticates unary RPCs before they reach the handler.
+func (server *Server) UnaryAuthInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := server.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor authenticates streaming RPCs before they reach the handler.
+func (server *Server) StreamAuthInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := server.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticatedStream overrides the context of a server stream so that
+// stream handlers see the payload stored by StreamAuthInterceptor.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/HzTTT/simple_bank/token"
+)
+
+// JWKSPath is where the gateway publishes the keys tokens are signed with.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler serves the public keys that the tokens of the server can be
+// verified with. Only JWT keyrings publish their keys; for any other token
+// maker, PASETO keyrings included, it responds with 404.
+func (server *Server) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		publisher, ok := server.tokenMaker.(token.KeyPublisher)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		jwks, err := publisher.JWKS()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// retired keys drop out at the cutoff, so don't let clients hold on for long
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(jwks)
+	})
+}
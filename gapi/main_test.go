@@ -0,0 +1,90 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestConfig() util.Config {
+	return util.Config{
+		TokenSymmetricKey:       util.RandomString(32),
+		PageTokenKey:            util.RandomString(32),
+		AccessTokenDuration:     time.Minute,
+		LoginChallengeDuration:  time.Minute,
+		TOTPIssuer:              "Simple Bank",
+		LoginBackoffBase:        time.Second,
+		LoginLockoutThreshold:   10,
+		LoginIPLockoutThreshold: 50,
+		LoginLockoutDuration:    15 * time.Minute,
+	}
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	server, err := NewServer(newTestConfig(), store)
+	require.NoError(t, err)
+	return server
+}
+
+// newContextWithBearerToken returns an incoming context carrying a token of
+// username, as a gRPC client would send it.
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration, options ...token.PayloadOption) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, duration, options...)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user = db.User{
+		Username:        util.RandOwner(),
+		FullName:        util.RandOwner(),
+		Email:           util.RandomEmail(),
+		HashedPassword:  hashedPassword,
+		Role:            util.DepositorRole,
+		IsEmailVerified: true,
+	}
+	return user, password
+}
+
+// newAuthContext returns a context carrying the payload of a token for
+// username, as the auth interceptor hands it to handlers.
+func newAuthContext(t *testing.T, username string, role string, options ...token.PayloadOption) context.Context {
+	payload, err := token.NewPayload(username, role, time.Minute, options...)
+	require.NoError(t, err)
+	return contextWithAuthPayload(context.Background(), payload)
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:        util.RandomInt(1, 1000),
+		Owner:     owner,
+		Balance:   util.RandMoney(),
+		Currency:  util.RandCurrency(),
+		CreatedAt: time.Now().Truncate(time.Second),
+		Status:    db.AccountStatusActive,
+	}
+}
+
+func requireMatchAccount(t *testing.T, account db.Account, got *pb.Account) {
+	require.NotNil(t, got)
+	require.Equal(t, account.ID, got.GetId())
+	require.Equal(t, account.Owner, got.GetOwner())
+	require.Equal(t, account.Balance, got.GetBalance())
+	require.Equal(t, account.Currency, got.GetCurrency())
+	require.Equal(t, account.Status, got.GetStatus())
+}
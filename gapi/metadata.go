@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
+	userAgentHeader            = "user-agent"
+	xForwardedForHeader        = "x-forwarded-for"
+)
+
+// Metadata describes the client device behind an incoming request.
+type Metadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// gatewayRequestKey marks the contexts of requests that came in through the
+// gateway. Native gRPC callers can set any metadata they like, but not this.
+type gatewayRequestKey struct{}
+
+func contextWithGatewayRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gatewayRequestKey{}, true)
+}
+
+func isGatewayRequest(ctx context.Context) bool {
+	gateway, _ := ctx.Value(gatewayRequestKey{}).(bool)
+	return gateway
+}
+
+// extractMetadata reads the user agent and client IP of the caller.
+// Requests coming through the gateway carry the original HTTP values in
+// prefixed headers, while native gRPC calls use the peer address.
+func (server *Server) extractMetadata(ctx context.Context) *Metadata {
+	mtdt := &Metadata{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 && isGatewayRequest(ctx) {
+			mtdt.UserAgent = userAgents[0]
+		} else if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+
+		if forwardedFor := md.Get(xForwardedForHeader); len(forwardedFor) > 0 && isGatewayRequest(ctx) {
+			mtdt.ClientIP = server.clientIPFromForwardedFor(strings.Join(forwardedFor, ","))
+		}
+	}
+
+	if mtdt.ClientIP == "" && !isGatewayRequest(ctx) {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			mtdt.ClientIP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(mtdt.ClientIP); err == nil {
+				mtdt.ClientIP = host
+			}
+		}
+	}
+
+	return mtdt
+}
+
+// clientIPFromForwardedFor picks the client out of an X-Forwarded-For value.
+// The gateway appends the address the request came from, so the right-most
+// entry can be believed. Going left, entries are only believed as long as
+// the one to their right is a trusted proxy.
+func (server *Server) clientIPFromForwardedFor(forwardedFor string) string {
+	addresses := strings.Split(forwardedFor, ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil {
+			return ""
+		}
+		if i == 0 || !server.isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+func (server *Server) isTrustedProxy(ip net.IP) bool {
+	for _, network := range server.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses addresses and CIDR ranges of trusted proxies.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,136 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.10 ", ""})
+	require.NoError(t, err)
+	server := &Server{trustedProxies: trustedProxies}
+
+	peerAddr := &net.TCPAddr{IP: net.ParseIP("198.51.100.7"), Port: 52000}
+
+	testCases := []struct {
+		name      string
+		gateway   bool
+		md        metadata.MD
+		clientIP  string
+		userAgent string
+	}{
+		{
+			name:      "DirectPeer",
+			md:        metadata.Pairs(userAgentHeader, "grpc-go/1.0"),
+			clientIP:  "198.51.100.7",
+			userAgent: "grpc-go/1.0",
+		},
+		{
+			name: "DirectPeerSpoofedHeaders",
+			md: metadata.Pairs(
+				xForwardedForHeader, "203.0.113.9",
+				grpcGatewayUserAgentHeader, "spoofed",
+				userAgentHeader, "grpc-go/1.0",
+			),
+			clientIP:  "198.51.100.7",
+			userAgent: "grpc-go/1.0",
+		},
+		{
+			name:    "GatewayDirect",
+			gateway: true,
+			md: metadata.Pairs(
+				xForwardedForHeader, "203.0.113.9",
+				grpcGatewayUserAgentHeader, "Mozilla/5.0",
+			),
+			clientIP:  "203.0.113.9",
+			userAgent: "Mozilla/5.0",
+		},
+		{
+			name:     "GatewayBehindTrustedProxies",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9, 10.1.2.3, 192.0.2.10"),
+			clientIP: "203.0.113.9",
+		},
+		{
+			name:     "GatewaySpoofedBehindTrustedProxy",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "1.2.3.4, 203.0.113.9, 10.1.2.3"),
+			clientIP: "203.0.113.9",
+		},
+		{
+			name:     "GatewayBehindUntrustedProxy",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9, 198.51.100.20"),
+			clientIP: "198.51.100.20",
+		},
+		{
+			name:     "GatewayMultipleHeaders",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9", xForwardedForHeader, "10.1.2.3"),
+			clientIP: "203.0.113.9",
+		},
+		{
+			name:     "GatewayEmptyHeader",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, ""),
+			clientIP: "",
+		},
+		{
+			name:     "GatewayMalformedHeader",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9, not-an-ip"),
+			clientIP: "",
+		},
+		{
+			name:     "GatewayMalformedBehindTrustedProxy",
+			gateway:  true,
+			md:       metadata.Pairs(xForwardedForHeader, "not-an-ip, 10.1.2.3"),
+			clientIP: "",
+		},
+		{
+			name:     "GatewayWithoutHeader",
+			gateway:  true,
+			md:       metadata.MD{},
+			clientIP: "",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: peerAddr})
+			if tc.gateway {
+				ctx = contextWithGatewayRequest(ctx)
+			}
+
+			mtdt := server.extractMetadata(ctx)
+			require.Equal(t, tc.clientIP, mtdt.ClientIP)
+			require.Equal(t, tc.userAgent, mtdt.UserAgent)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	require.True(t, networks[1].Contains(net.ParseIP("192.0.2.10")))
+	require.False(t, networks[1].Contains(net.ParseIP("192.0.2.11")))
+	require.True(t, networks[2].Contains(net.ParseIP("2001:db8::1")))
+
+	networks, err = parseTrustedProxies(nil)
+	require.NoError(t, err)
+	require.Empty(t, networks)
+
+	_, err = parseTrustedProxies([]string{"proxy.local"})
+	require.Error(t, err)
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
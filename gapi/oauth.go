@@ -0,0 +1,17 @@
+package gapi
+
+import (
+	"net/http"
+
+	"github.com/HzTTT/simple_bank/oauth"
+)
+
+// OAuthTokenPath is where the gateway serves the OAuth token endpoint.
+const OAuthTokenPath = oauth.TokenPath
+
+// OAuthTokenHandler serves the token endpoint of the client credentials
+// grant. It is plain HTTP rather than an RPC, as OAuth clients expect a
+// form encoded request.
+func (server *Server) OAuthTokenHandler() http.Handler {
+	return server.issuer
+}
@@ -0,0 +1,18 @@
+package gapi
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minPageSize = 5
+	maxPageSize = 10
+)
+
+func validPageSize(pageSize int32) error {
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return status.Errorf(codes.InvalidArgument, "page_size must be between %d and %d", minPageSize, maxPageSize)
+	}
+	return nil
+}
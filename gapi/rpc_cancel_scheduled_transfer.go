@@ -0,0 +1,20 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+)
+
+func (server *Server) CancelScheduledTransfer(ctx context.Context, req *pb.CancelScheduledTransferRequest) (*pb.CancelScheduledTransferResponse, error) {
+	schedule, err := server.updateScheduledTransferStatus(ctx, req.GetId(), db.ScheduledTransferStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.CancelScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(schedule),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,27 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/HzTTT/simple_bank/pb"
+)
+
+// ChangePassword is UpdateUser for the password of the caller alone.
+func (server *Server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newPassword := req.GetNewPassword()
+	_, err = server.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Username:        authPayload.Username,
+		Password:        &newPassword,
+		CurrentPassword: req.GetCurrentPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ChangePasswordResponse{}, nil
+}
@@ -0,0 +1,20 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+)
+
+func (server *Server) CloseAccount(ctx context.Context, req *pb.CloseAccountRequest) (*pb.CloseAccountResponse, error) {
+	account, err := server.updateAccountStatus(ctx, req.GetId(), db.AccountStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.CloseAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConfirmTOTP enables two-factor authentication and hands out the recovery
+// codes.
+func (server *Server) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	result, err := server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username: authPayload.Username,
+		Code:     req.GetCode(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTOTPNotEnrolled):
+			return nil, status.Errorf(codes.NotFound, "%s", err)
+		case errors.Is(err, db.ErrTOTPAlreadyEnabled):
+			return nil, status.Errorf(codes.AlreadyExists, "%s", err)
+		case errors.Is(err, db.ErrTwoFactorCodeInvalid):
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enable totp: %s", err)
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: result.RecoveryCodes}, nil
+}
@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/HzTTT/simple_bank/apikey"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAPIKey issues an API key for the caller. The key is only ever
+// returned here; the server keeps nothing but its hash.
+func (server *Server) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" || len(req.GetName()) > 64 {
+		return nil, status.Errorf(codes.InvalidArgument, "name must have 1 to 64 characters")
+	}
+	if len(req.GetScopes()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one scope is required")
+	}
+	for _, scope := range req.GetScopes() {
+		if !policy.IsSupportedScope(scope) {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported scope: %s", scope)
+		}
+	}
+	expiresInDays := int(req.GetExpiresInDays())
+	if expiresInDays == 0 {
+		expiresInDays = apikey.DefaultLifetimeDays
+	}
+	if expiresInDays < 1 || expiresInDays > apikey.MaxLifetimeDays {
+		return nil, status.Errorf(codes.InvalidArgument, "expires_in_days must be between 1 and %d", apikey.MaxLifetimeDays)
+	}
+
+	key, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate API key: %s", err)
+	}
+
+	apiKey, err := server.store.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		Username:  authPayload.Username,
+		Name:      req.GetName(),
+		KeyHash:   hash,
+		Scopes:    req.GetScopes(),
+		ExpiresAt: time.Now().AddDate(0, 0, expiresInDays),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create API key: %s", err)
+	}
+
+	rsp := &pb.CreateAPIKeyResponse{
+		Key:    key,
+		ApiKey: convertAPIKey(apiKey),
+	}
+	return rsp, nil
+}
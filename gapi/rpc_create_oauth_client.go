@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateOAuthClient registers the client of a partner service, acting on the
+// accounts of a user. The client secret is only ever returned here.
+func (server *Server) CreateOAuthClient(ctx context.Context, req *pb.CreateOAuthClientRequest) (*pb.CreateOAuthClientResponse, error) {
+	if req.GetName() == "" || len(req.GetName()) > 64 {
+		return nil, status.Errorf(codes.InvalidArgument, "name must have 1 to 64 characters")
+	}
+	if req.GetUsername() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "username is required")
+	}
+	if len(req.GetScopes()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one scope is required")
+	}
+	for _, scope := range req.GetScopes() {
+		if !policy.IsSupportedScope(scope) {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported scope: %s", scope)
+		}
+	}
+
+	clientID, secret, hash, err := oauth.NewClientCredentials()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate client credentials: %s", err)
+	}
+
+	client, err := server.store.CreateOAuthClient(ctx, db.CreateOAuthClientParams{
+		ClientID:   clientID,
+		SecretHash: hash,
+		Name:       req.GetName(),
+		Username:   req.GetUsername(),
+		Scopes:     req.GetScopes(),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create client: %s", err)
+	}
+
+	rsp := &pb.CreateOAuthClientResponse{
+		ClientSecret: secret,
+		Client:       convertOAuthClient(client),
+	}
+	return rsp, nil
+}
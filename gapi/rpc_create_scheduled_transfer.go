@@ -0,0 +1,99 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/scheduler"
+	"github.com/HzTTT/simple_bank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateScheduledTransfer(ctx context.Context, req *pb.CreateScheduledTransferRequest) (*pb.CreateScheduledTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetFromAccountId() < 1 || req.GetToAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "account ids must be positive")
+	}
+	if req.GetAmount() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "amount must be positive")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported currency: %s", req.GetCurrency())
+	}
+	if req.GetIntervalSeconds() < 0 || req.GetMaxRuns() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "interval_seconds and max_runs must not be negative")
+	}
+
+	startAt := time.Now()
+	if req.StartAt != nil {
+		startAt = req.GetStartAt().AsTime()
+	}
+
+	rule, err := scheduler.ParseRule(req.GetCronExpression(), time.Duration(req.GetIntervalSeconds())*time.Second, startAt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	firstRun := scheduler.FirstRun(rule, startAt)
+	if req.EndAt != nil && firstRun.After(req.GetEndAt().AsTime()) {
+		return nil, status.Errorf(codes.InvalidArgument, "schedule ends before its first run")
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(authPayload, policy.OperateAccount, fromAccount.Owner); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	if err := server.requireVerifiedEmail(ctx, fromAccount.Owner); err != nil {
+		return nil, err
+	}
+
+	if _, err := server.findAccount(ctx, req.GetToAccountId()); err != nil {
+		return nil, err
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+		StartAt:       startAt,
+		NextRunAt:     sql.NullTime{Time: firstRun, Valid: true},
+	}
+	if req.GetCronExpression() != "" {
+		arg.CronExpression = sql.NullString{String: req.GetCronExpression(), Valid: true}
+	}
+	if req.GetIntervalSeconds() != 0 {
+		arg.IntervalSeconds = sql.NullInt64{Int64: req.GetIntervalSeconds(), Valid: true}
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: req.GetEndAt().AsTime(), Valid: true}
+	}
+	if req.GetMaxRuns() != 0 {
+		arg.MaxRuns = sql.NullInt32{Int32: req.GetMaxRuns(), Valid: true}
+	}
+
+	schedule, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.CreateScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(schedule),
+	}
+	return rsp, nil
+}
@@ -6,6 +6,7 @@ import (
 	db "github.com/HzTTT/simple_bank/db/sqlc"
 	"github.com/HzTTT/simple_bank/pb"
 	"github.com/HzTTT/simple_bank/util"
+	"github.com/HzTTT/simple_bank/worker"
 	"github.com/lib/pq"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,14 +18,22 @@ func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.GetUsername(),
-		HashedPassword: hashedPassword,
-		FullName:       req.GetFullName(),
-		Email:          req.GetEmail(),
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.GetUsername(),
+			HashedPassword: hashedPassword,
+			FullName:       req.GetFullName(),
+			Email:          req.GetEmail(),
+		},
+		AfterCreate: func(q db.Querier, user db.User) error {
+			_, err := worker.TaskSendWelcomeEmail.Enqueue(ctx, q, worker.SendWelcomeEmailPayload{
+				Username: user.Username,
+			})
+			return err
+		},
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -0,0 +1,27 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DisableOAuthClient stops issuing tokens to a client and rejects the
+// tokens it already got.
+func (server *Server) DisableOAuthClient(ctx context.Context, req *pb.DisableOAuthClientRequest) (*pb.DisableOAuthClientResponse, error) {
+	client, err := server.store.DisableOAuthClient(ctx, req.GetClientId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "client not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to disable client: %s", err)
+	}
+
+	rsp := &pb.DisableOAuthClientResponse{
+		Client: convertOAuthClient(client),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,43 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EnrollTOTP starts setting up two-factor authentication with a new secret.
+// It only takes effect once ConfirmTOTP gets a code generated from it.
+func (server *Server) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := util.RandomTOTPSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate totp secret: %s", err)
+	}
+
+	_, err = server.store.CreateTOTPCredential(ctx, db.CreateTOTPCredentialParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.AlreadyExists, "%s", db.ErrTOTPAlreadyEnabled)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create totp credential: %s", err)
+	}
+
+	rsp := &pb.EnrollTOTPResponse{
+		Secret:     secret,
+		OtpauthUri: util.TOTPURI(server.config.TOTPIssuer, authPayload.Username, secret),
+	}
+	return rsp, nil
+}
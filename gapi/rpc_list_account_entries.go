@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccountEntries(ctx context.Context, req *pb.ListAccountEntriesRequest) (*pb.ListAccountEntriesResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id: %d", req.GetAccountId())
+	}
+	if err := validPageSize(req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	scope := pagination.EntriesScope(req.GetAccountId())
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.GetPageToken())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	arg := db.ListAccountEntriesParams{
+		AccountID:       req.GetAccountId(),
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageLimit:       req.GetPageSize() + 1,
+	}
+
+	if req.StartTime != nil {
+		arg.StartTime = sql.NullTime{Time: req.GetStartTime().AsTime(), Valid: true}
+	}
+	if req.EndTime != nil {
+		arg.EndTime = sql.NullTime{Time: req.GetEndTime().AsTime(), Valid: true}
+	}
+	if arg.StartTime.Valid && arg.EndTime.Valid && !arg.StartTime.Time.Before(arg.EndTime.Time) {
+		return nil, status.Errorf(codes.InvalidArgument, "start_time must be before end_time")
+	}
+
+	switch req.GetDirection() {
+	case "":
+	case db.EntryDirectionDebit, db.EntryDirectionCredit:
+		arg.Direction = sql.NullString{String: req.GetDirection(), Valid: true}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "direction must be %s or %s", db.EntryDirectionDebit, db.EntryDirectionCredit)
+	}
+
+	if req.MinAmount != nil {
+		if req.GetMinAmount() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "min_amount must not be negative")
+		}
+		arg.MinAmount = sql.NullInt64{Int64: req.GetMinAmount(), Valid: true}
+	}
+	if req.MaxAmount != nil {
+		if req.GetMaxAmount() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "max_amount must not be negative")
+		}
+		arg.MaxAmount = sql.NullInt64{Int64: req.GetMaxAmount(), Valid: true}
+	}
+	if arg.MinAmount.Valid && arg.MaxAmount.Valid && arg.MinAmount.Int64 > arg.MaxAmount.Int64 {
+		return nil, status.Errorf(codes.InvalidArgument, "min_amount must not be greater than max_amount")
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if err := policy.Authorize(authPayload, policy.ViewAccount, account.Owner); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list entries: %s", err)
+	}
+
+	entries, nextPageToken := pagination.NextPage(server.pageTokens, scope, entries, req.GetPageSize(), func(entry db.ListAccountEntriesRow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	})
+
+	rsp := &pb.ListAccountEntriesResponse{NextPageToken: nextPageToken}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, convertAccountEntry(entry))
+	}
+	return rsp, nil
+}
@@ -0,0 +1,232 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	transferID := util.RandomInt(1, 1000)
+	counterparty := util.RandomInt(1001, 2000)
+	entries := []db.ListAccountEntriesRow{
+		{
+			ID:                    1,
+			AccountID:             account.ID,
+			Amount:                -util.RandMoney(),
+			CreatedAt:             time.Now().Truncate(time.Second),
+			TransferID:            &transferID,
+			TransferFromAccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			TransferToAccountID:   sql.NullInt64{Int64: counterparty, Valid: true},
+		},
+		{
+			ID:        2,
+			AccountID: account.ID,
+			Amount:    util.RandMoney(),
+			CreatedAt: time.Now().Truncate(time.Second),
+		},
+	}
+	pageSize := int32(5)
+	startTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	endTime := time.Now().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountEntriesRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T) context.Context
+		checkResponse func(t *testing.T, res *pb.ListAccountEntriesResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListAccountEntriesRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					PageLimit: pageSize + 1,
+				}
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetEntries(), len(entries))
+				require.Empty(t, res.GetNextPageToken())
+
+				require.Equal(t, entries[0].Amount, res.GetEntries()[0].GetEntry().GetAmount())
+				require.Equal(t, transferID, res.GetEntries()[0].GetEntry().GetTransferId())
+				require.Equal(t, counterparty, res.GetEntries()[0].GetCounterpartyAccountId())
+				require.Zero(t, res.GetEntries()[1].GetCounterpartyAccountId())
+			},
+		},
+		{
+			name: "Filters",
+			req: &pb.ListAccountEntriesRequest{
+				AccountId: account.ID,
+				PageSize:  pageSize,
+				StartTime: timestamppb.New(startTime),
+				EndTime:   timestamppb.New(endTime),
+				Direction: db.EntryDirectionDebit,
+				MinAmount: proto.Int64(10),
+				MaxAmount: proto.Int64(100),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					StartTime: sql.NullTime{Time: startTime, Valid: true},
+					EndTime:   sql.NullTime{Time: endTime, Valid: true},
+					Direction: sql.NullString{String: db.EntryDirectionDebit, Valid: true},
+					MinAmount: sql.NullInt64{Int64: 10, Valid: true},
+					MaxAmount: sql.NullInt64{Int64: 100, Valid: true},
+					PageLimit: pageSize + 1,
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, got db.ListAccountEntriesParams) ([]db.ListAccountEntriesRow, error) {
+						require.True(t, got.StartTime.Time.Equal(arg.StartTime.Time))
+						require.True(t, got.EndTime.Time.Equal(arg.EndTime.Time))
+						got.StartTime.Time, got.EndTime.Time = arg.StartTime.Time, arg.EndTime.Time
+						require.Equal(t, arg, got)
+						return entries[:1], nil
+					})
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetEntries(), 1)
+			},
+		},
+		{
+			name: "InvalidTimeRange",
+			req: &pb.ListAccountEntriesRequest{
+				AccountId: account.ID,
+				PageSize:  pageSize,
+				StartTime: timestamppb.New(endTime),
+				EndTime:   timestamppb.New(startTime),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidDirection",
+			req: &pb.ListAccountEntriesRequest{
+				AccountId: account.ID,
+				PageSize:  pageSize,
+				Direction: "sideways",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidAmountRange",
+			req: &pb.ListAccountEntriesRequest{
+				AccountId: account.ID,
+				PageSize:  pageSize,
+				MinAmount: proto.Int64(100),
+				MaxAmount: proto.Int64(10),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "OthersAccount",
+			req:  &pb.ListAccountEntriesRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, "other", util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  &pb.ListAccountEntriesRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ListAccountEntriesRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountEntriesResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.ListAccountEntries(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListAccountTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	pageSize := int32(5)
+	transfers := make([]db.Transfer, pageSize+1)
+	for i := range transfers {
+		transfers[i] = db.Transfer{
+			ID:            int64(len(transfers) - i),
+			FromAccountID: account.ID,
+			ToAccountID:   util.RandomInt(1001, 2000),
+			Amount:        util.RandMoney(),
+			CreatedAt:     time.Now().Truncate(time.Second),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountTransfersRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T) context.Context
+		checkResponse func(t *testing.T, res *pb.ListAccountTransfersResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListTransfersParams{
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					PageLimit: pageSize + 1,
+				}
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetTransfers(), int(pageSize))
+				for i, transfer := range res.GetTransfers() {
+					require.Equal(t, transfers[i].ID, transfer.GetId())
+					require.Equal(t, transfers[i].Amount, transfer.GetAmount())
+				}
+				require.NotEmpty(t, res.GetNextPageToken())
+			},
+		},
+		{
+			name: "BankerViewsOthersTransfers",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(1).Return(transfers[:1], nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, "banker", util.BankerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetTransfers(), 1)
+				require.Empty(t, res.GetNextPageToken())
+			},
+		},
+		{
+			name: "OthersAccount",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, "other", util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: 1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ListAccountTransfersRequest{AccountId: account.ID, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountTransfersResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.ListAccountTransfers(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
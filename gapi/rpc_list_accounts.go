@@ -0,0 +1,59 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validPageSize(req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	owner := authPayload.Username
+	if req.GetOwner() != "" {
+		owner = req.GetOwner()
+	}
+	if err := policy.Authorize(authPayload, policy.ViewAccount, owner); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	scope := pagination.AccountsScope(owner)
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.GetPageToken())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	arg := db.ListAccountsParams{
+		Owner:           owner,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageLimit:       req.GetPageSize() + 1,
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list accounts: %s", err)
+	}
+
+	accounts, nextPageToken := pagination.NextPage(server.pageTokens, scope, accounts, req.GetPageSize(), func(account db.Account) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: account.CreatedAt, ID: account.ID}
+	})
+
+	rsp := &pb.ListAccountsResponse{NextPageToken: nextPageToken}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+	return rsp, nil
+}
@@ -0,0 +1,185 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListAccountsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	n := 6
+	accounts := make([]db.Account, n)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+	}
+	pageSize := int32(5)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T) context.Context
+		checkResponse func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListAccountsRequest{PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Owner:     user.Username,
+					PageLimit: pageSize + 1,
+				}
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), int(pageSize))
+				for i, account := range res.GetAccounts() {
+					requireMatchAccount(t, accounts[i], account)
+				}
+
+				// the next page starts after the last account returned
+				last := accounts[pageSize-1]
+				cursor, err := server.pageTokens.Decode(pagination.AccountsScope(user.Username), res.GetNextPageToken())
+				require.NoError(t, err)
+				require.Equal(t, last.ID, cursor.ID)
+			},
+		},
+		{
+			name: "NextPage",
+			req:  &pb.ListAccountsRequest{PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+						require.True(t, arg.CursorCreatedAt.Valid)
+						require.Equal(t, accounts[0].ID, arg.CursorID.Int64)
+						return accounts[1:3], nil
+					})
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), 2)
+				require.Empty(t, res.GetNextPageToken())
+			},
+		},
+		{
+			name: "BankerListsOthersAccounts",
+			req:  &pb.ListAccountsRequest{Owner: user.Username, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						return accounts[:2], nil
+					})
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, "banker", util.BankerRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), 2)
+			},
+		},
+		{
+			name: "OthersAccounts",
+			req:  &pb.ListAccountsRequest{Owner: user.Username, PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, "other", util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req:  &pb.ListAccountsRequest{PageSize: 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "ForeignPageToken",
+			req:  &pb.ListAccountsRequest{PageSize: pageSize, PageToken: "not-a-page-token"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ListAccountsRequest{PageSize: pageSize},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username, util.DepositorRole)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			req := tc.req
+			if tc.name == "NextPage" {
+				req.PageToken = server.pageTokens.Encode(pagination.AccountsScope(user.Username), pagination.Cursor{
+					CreatedAt: accounts[0].CreatedAt,
+					ID:        accounts[0].ID,
+				})
+			}
+			res, err := server.ListAccounts(tc.buildContext(t), req)
+			tc.checkResponse(t, server, res, err)
+		})
+	}
+}
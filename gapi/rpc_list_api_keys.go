@@ -0,0 +1,31 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListAPIKeys returns every API key of the caller, newest first, including
+// revoked and expired ones.
+func (server *Server) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := server.store.ListAPIKeys(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list API keys: %s", err)
+	}
+
+	rsp := &pb.ListAPIKeysResponse{
+		ApiKeys: make([]*pb.APIKey, len(apiKeys)),
+	}
+	for i, apiKey := range apiKeys {
+		rsp.ApiKeys[i] = convertAPIKey(apiKey)
+	}
+	return rsp, nil
+}
@@ -0,0 +1,25 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListOAuthClients returns every registered client, newest first.
+func (server *Server) ListOAuthClients(ctx context.Context, req *pb.ListOAuthClientsRequest) (*pb.ListOAuthClientsResponse, error) {
+	clients, err := server.store.ListOAuthClients(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list clients: %s", err)
+	}
+
+	rsp := &pb.ListOAuthClientsResponse{
+		Clients: make([]*pb.OAuthClient, len(clients)),
+	}
+	for i, client := range clients {
+		rsp.Clients[i] = convertOAuthClient(client)
+	}
+	return rsp, nil
+}
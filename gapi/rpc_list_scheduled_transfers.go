@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListScheduledTransfers(ctx context.Context, req *pb.ListScheduledTransfersRequest) (*pb.ListScheduledTransfersResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validPageSize(req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	scope := pagination.ScheduledTransfersScope(authPayload.Username)
+	cursorCreatedAt, cursorID, err := server.pageTokens.DecodeKeyset(scope, req.GetPageToken())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	schedules, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:           authPayload.Username,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageLimit:       req.GetPageSize() + 1,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled transfers: %s", err)
+	}
+
+	schedules, nextPageToken := pagination.NextPage(server.pageTokens, scope, schedules, req.GetPageSize(), func(schedule db.ScheduledTransfer) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: schedule.CreatedAt, ID: schedule.ID}
+	})
+
+	rsp := &pb.ListScheduledTransfersResponse{NextPageToken: nextPageToken}
+	for _, schedule := range schedules {
+		rsp.ScheduledTransfers = append(rsp.ScheduledTransfers, convertScheduledTransfer(schedule))
+	}
+	return rsp, nil
+}
@@ -3,51 +3,91 @@ package gapi
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	db "github.com/HzTTT/simple_bank/db/sqlc"
 	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/throttle"
 	"github.com/HzTTT/simple_bank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// LoginUser checks the password of a user. Users with two-factor
+// authentication get a login challenge to complete with LoginUserTOTP instead
+// of tokens.
 func (server *Server)LoginUser(ctx context.Context,req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	clientIP := server.extractMetadata(ctx).ClientIP
+	var user db.User
+	err := server.loginGuard.Attempt(ctx, req.GetUsername(), clientIP, func() error {
+		var err error
+		user, err = server.store.GetUser(ctx, req.GetUsername())
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return throttle.Failed(err)
+			}
+			return fmt.Errorf("failed to get user:%w", err)
+		}
 
-	user, err := server.store.GetUser(ctx, req.GetUsername())
+		err = util.CheckPassword(req.Password, user.HashedPassword)
+		if err != nil {
+			return throttle.Failed(err)
+		}
+		return nil
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "username not fund")
+		var throttled *throttle.Error
+		switch {
+		case errors.As(err, &throttled):
+			return nil, throttledError(throttled)
+		case errors.Is(err, throttle.ErrLoginFailed):
+			// the same for unknown users, so usernames can't be probed
+			return nil, status.Errorf(codes.Unauthenticated, "incorrect username or password")
+		default:
+			return nil, status.Errorf(codes.Internal, "%s", err)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get user:%s", err)
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
-	if err != nil {
-		return nil, status.Errorf(codes.Unavailable, "password not right:")
+	credential, err := server.store.GetTOTPCredential(ctx, user.Username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to get totp credential: %s", err)
+	}
+	if err == nil && credential.IsEnabled {
+		return server.createLoginChallenge(ctx, user)
 	}
 
-	assessToken, accseePayload, err := server.tokenMaker.CreateToken(req.GetUsername(), server.config.AccessTokenDuration)
+	return server.createLoginSession(ctx, user)
+}
+
+func (server *Server) createLoginSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	assessToken, accseePayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal,err.Error())
 	}
 
 	refreshToken, refreshpayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal,err.Error())
 	}
 
+	mtdt := server.extractMetadata(ctx)
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshpayload.ID,
 		Username:     user.Username,
 		RefreshToken: refreshToken,
-		UserAgent:    "",
-		ClientIp:     "",
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
 		IsBlocked:    false,
 		ExpiresAt:    refreshpayload.ExpiredAt,
+		FamilyID:     refreshpayload.ID,
 	})
 
 	if err != nil {
@@ -59,9 +99,22 @@ func (server *Server)LoginUser(ctx context.Context,req *pb.LoginUserRequest) (*p
 		AccessToken:           assessToken,
 		AccessTokenExpiresAt:  timestamppb.New(accseePayload.ExpiredAt),
 		RefreshToken:          refreshToken,
-		RefreshTokenExpiresAt: timestamppb.New(accseePayload.ExpiredAt),
+		RefreshTokenExpiresAt: timestamppb.New(refreshpayload.ExpiredAt),
 		User:                  convertUser(user),
 	}
 
 	return &rsp, nil
-}
\ No newline at end of file
+}
+
+// throttledError reports a throttled login as ResourceExhausted, with the
+// time to wait attached as RetryInfo.
+func throttledError(throttled *throttle.Error) error {
+	st := status.New(codes.ResourceExhausted, throttled.Error())
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(throttled.RetryAfter),
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoginUserTOTP completes a login challenge with a TOTP or recovery code.
+func (server *Server) LoginUserTOTP(ctx context.Context, req *pb.LoginUserTOTPRequest) (*pb.LoginUserResponse, error) {
+	if req.GetChallengeToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge_token is required")
+	}
+	if req.GetCode() == "" && req.GetRecoveryCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code or recovery_code is required")
+	}
+
+	user, err := server.store.CompleteLoginChallengeTx(ctx, db.CompleteLoginChallengeTxParams{
+		Token:        req.GetChallengeToken(),
+		Code:         req.GetCode(),
+		RecoveryCode: req.GetRecoveryCode(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrLoginChallengeInvalid),
+			errors.Is(err, db.ErrLoginChallengeUsed),
+			errors.Is(err, db.ErrLoginChallengeExpired),
+			errors.Is(err, db.ErrLoginChallengeLocked),
+			errors.Is(err, db.ErrTwoFactorCodeInvalid):
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to complete login challenge: %s", err)
+	}
+
+	return server.createLoginSession(ctx, user)
+}
@@ -0,0 +1,26 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/refresh"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Logout revokes the session of the given refresh token. Access tokens don't
+// name their session, so the refresh token is what identifies it.
+func (server *Server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	session, err := refresh.Verify(ctx, server.store, server.tokenMaker, req.GetRefreshToken())
+	if err != nil {
+		return nil, refreshError(err)
+	}
+
+	_, err = server.store.BlockSession(ctx, session.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %s", err)
+	}
+
+	return &pb.LogoutResponse{}, nil
+}
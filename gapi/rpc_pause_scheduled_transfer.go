@@ -0,0 +1,20 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+)
+
+func (server *Server) PauseScheduledTransfer(ctx context.Context, req *pb.PauseScheduledTransferRequest) (*pb.PauseScheduledTransferResponse, error) {
+	schedule, err := server.updateScheduledTransferStatus(ctx, req.GetId(), db.ScheduledTransferStatusPaused)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.PauseScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(schedule),
+	}
+	return rsp, nil
+}
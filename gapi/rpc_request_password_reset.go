@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"net/mail"
+
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestPasswordReset queues a reset link for a verified email address. Its
+// answer never tells whether the address belongs to anyone.
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	if _, err := mail.ParseAddress(req.GetEmail()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid email: %s", err)
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &pb.RequestPasswordResetResponse{}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+	if !user.IsEmailVerified {
+		return &pb.RequestPasswordResetResponse{}, nil
+	}
+
+	_, err = worker.TaskSendPasswordResetEmail.Enqueue(ctx, server.store, worker.SendPasswordResetEmailPayload{
+		Username: user.Username,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to queue password reset email: %s", err)
+	}
+
+	return &pb.RequestPasswordResetResponse{}, nil
+}
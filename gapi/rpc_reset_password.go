@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const minPasswordLength = 6
+
+func (server *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+	if len(req.GetNewPassword()) < minPasswordLength {
+		return nil, status.Errorf(codes.InvalidArgument, "password must be at least %d characters", minPasswordLength)
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetNewPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+	}
+
+	_, err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		Token:          req.GetToken(),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrPasswordResetTokenInvalid):
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		case errors.Is(err, db.ErrPasswordResetTokenUsed),
+			errors.Is(err, db.ErrPasswordResetTokenExpired):
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %s", err)
+	}
+
+	return &pb.ResetPasswordResponse{}, nil
+}
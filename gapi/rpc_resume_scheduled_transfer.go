@@ -0,0 +1,20 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+)
+
+func (server *Server) ResumeScheduledTransfer(ctx context.Context, req *pb.ResumeScheduledTransferRequest) (*pb.ResumeScheduledTransferResponse, error) {
+	schedule, err := server.updateScheduledTransferStatus(ctx, req.GetId(), db.ScheduledTransferStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ResumeScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(schedule),
+	}
+	return rsp, nil
+}
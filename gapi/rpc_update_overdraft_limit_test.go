@@ -0,0 +1,100 @@
+package gapi
+
+import (
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateOverdraftLimitAPI(t *testing.T) {
+	account := randomAccount(util.RandOwner())
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateOverdraftLimitRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.UpdateOverdraftLimitRequest{Id: account.ID, OverdraftLimit: 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountOverdraftLimitParams{
+					ID:             account.ID,
+					OverdraftLimit: 500,
+				}
+				result := account
+				result.OverdraftLimit = 500
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error) {
+				require.NoError(t, err)
+				requireMatchAccount(t, account, res.GetAccount())
+				require.Equal(t, int64(500), res.GetAccount().GetOverdraftLimit())
+			},
+		},
+		{
+			name: "NegativeLimit",
+			req:  &pb.UpdateOverdraftLimitRequest{Id: account.ID, OverdraftLimit: -1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.UpdateOverdraftLimitRequest{Id: 0, OverdraftLimit: 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.UpdateOverdraftLimitRequest{Id: account.ID, OverdraftLimit: 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.UpdateOverdraftLimitRequest{Id: account.ID, OverdraftLimit: 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateOverdraftLimitResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newAuthContext(t, "banker", util.BankerRole)
+			res, err := server.UpdateOverdraftLimit(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"net/mail"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/HzTTT/simple_bank/worker"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpdateUser changes the fields set in the request.
+func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(authPayload, policy.UpdateUser, req.GetUsername()); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+	if req.FullName != nil && req.GetFullName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "full name must not be empty")
+	}
+	if req.Email != nil {
+		if _, err := mail.ParseAddress(req.GetEmail()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid email: %s", err)
+		}
+	}
+	if req.Password != nil && len(req.GetPassword()) < minPasswordLength {
+		return nil, status.Errorf(codes.InvalidArgument, "password must be at least %d characters", minPasswordLength)
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	arg := db.UpdateUserTxParams{
+		Username: user.Username,
+	}
+
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: req.GetFullName(), Valid: true}
+	}
+
+	emailChanged := req.Email != nil && req.GetEmail() != user.Email
+	if (req.Password != nil || emailChanged) && authPayload.Username == user.Username {
+		if err := util.CheckPassword(req.GetCurrentPassword(), user.HashedPassword); err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "incorrect current password")
+		}
+	}
+
+	if req.Password != nil {
+		hashedPassword, err := util.HashPassword(req.GetPassword())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+		}
+		arg.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+	}
+
+	if emailChanged {
+		oldEmail := user.Email
+		arg.Email = sql.NullString{String: req.GetEmail(), Valid: true}
+		arg.AfterUpdate = func(q db.Querier, user db.User) error {
+			return worker.EnqueueEmailChange(ctx, q, user.Username, oldEmail)
+		}
+	}
+
+	user, err = server.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Errorf(codes.AlreadyExists, "email already in use: %s", err)
+			}
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user: %s", err)
+	}
+
+	rsp := &pb.UpdateUserResponse{
+		User: convertUser(user),
+	}
+	return rsp, nil
+}
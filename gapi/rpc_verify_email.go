@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.GetEmailId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid email id: %d", req.GetEmailId())
+	}
+	if req.GetSecretCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "secret code is required")
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:    req.GetEmailId(),
+		SecretCode: req.GetSecretCode(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrVerifyEmailInvalid):
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		case errors.Is(err, db.ErrVerifyEmailUsed),
+			errors.Is(err, db.ErrVerifyEmailExpired):
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %s", err)
+	}
+
+	rsp := &pb.VerifyEmailResponse{
+		IsVerified: result.User.IsEmailVerified,
+	}
+	return rsp, nil
+}
@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/scheduler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// updateScheduledTransferStatus backs the pause, resume and cancel RPCs.
+func (server *Server) updateScheduledTransferStatus(ctx context.Context, id int64, scheduleStatus string) (db.ScheduledTransfer, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return db.ScheduledTransfer{}, err
+	}
+
+	if id < 1 {
+		return db.ScheduledTransfer{}, status.Errorf(codes.InvalidArgument, "invalid scheduled transfer id: %d", id)
+	}
+
+	schedule, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return schedule, status.Errorf(codes.NotFound, "scheduled transfer not found")
+		}
+		return schedule, status.Errorf(codes.Internal, "failed to get scheduled transfer: %s", err)
+	}
+
+	if err := policy.Authorize(authPayload, policy.OperateAccount, schedule.Owner); err != nil {
+		return schedule, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+
+	arg := db.UpdateScheduledTransferStatusParams{
+		ID:           schedule.ID,
+		Status:       scheduleStatus,
+		FromStatuses: db.ScheduledTransferSourceStatuses(scheduleStatus),
+	}
+
+	if scheduleStatus == db.ScheduledTransferStatusActive {
+		rule, err := scheduler.RuleOf(schedule)
+		if err != nil {
+			return schedule, status.Errorf(codes.Internal, "failed to parse schedule rule: %s", err)
+		}
+		nextRunAt, ok := scheduler.ResumeAt(schedule, rule, time.Now())
+		if !ok {
+			return schedule, status.Errorf(codes.FailedPrecondition, "scheduled transfer has no runs left before its end date")
+		}
+		arg.NextRunAt = sql.NullTime{Time: nextRunAt, Valid: true}
+	}
+
+	schedule, err = server.store.UpdateScheduledTransferStatus(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return schedule, status.Errorf(codes.FailedPrecondition, "cannot move scheduled transfer to %s", scheduleStatus)
+		}
+		return schedule, status.Errorf(codes.Internal, "failed to update scheduled transfer: %s", err)
+	}
+
+	return schedule, nil
+}
@@ -2,8 +2,14 @@ package gapi
 
 import (
 	"fmt"
-	"github.com/HzTTT/simple_bank/pb"
+	"net"
+
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/fx"
+	"github.com/HzTTT/simple_bank/oauth"
+	"github.com/HzTTT/simple_bank/pagination"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/throttle"
 	"github.com/HzTTT/simple_bank/token"
 	"github.com/HzTTT/simple_bank/util"
 )
@@ -13,19 +19,39 @@ type Server struct {
 	config     util.Config
 	store      db.Store
 	tokenMaker token.Maker
+	fxProvider fx.FXRateProvider
+	pageTokens *pagination.Signer
+	loginGuard *throttle.Guard
+	issuer     *oauth.Issuer
+	// trustedProxies are the proxies whose X-Forwarded-For entries are
+	// believed for gateway requests.
+	trustedProxies []*net.IPNet
 }
 
 // NewServer creates a new grpc server.
 func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMakerFromConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	pageTokens, err := pagination.NewSigner(config.PageTokenKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create page token signer: %w", err)
+	}
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse trusted proxies: %w", err)
+	}
 	server := &Server{
-		store:      store,
-		config:     config,
-		tokenMaker: tokenMaker,
+		store:          store,
+		config:         config,
+		tokenMaker:     tokenMaker,
+		fxProvider:     fx.NewDBRateProvider(store),
+		pageTokens:     pageTokens,
+		loginGuard:     throttle.NewGuard(store, config),
+		issuer:         oauth.NewIssuer(store, tokenMaker, config.AccessTokenDuration),
+		trustedProxies: trustedProxies,
 	}
 
 	return server, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,22 @@
+package gapi
+
+import (
+	"errors"
+
+	"github.com/HzTTT/simple_bank/refresh"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// refreshError converts an error of refresh.Verify or refresh.BlockReused
+// to a status.
+func refreshError(err error) error {
+	switch {
+	case errors.Is(err, refresh.ErrSessionNotFound):
+		return status.Errorf(codes.NotFound, "%s", err)
+	case errors.Is(err, refresh.ErrInvalidToken):
+		return status.Errorf(codes.Unauthenticated, "%s", err)
+	default:
+		return status.Errorf(codes.Internal, "%s", err)
+	}
+}
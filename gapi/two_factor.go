@@ -0,0 +1,36 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) createLoginChallenge(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	challengeToken, err := util.RandomSecret(32)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate challenge token: %s", err)
+	}
+
+	challenge, err := server.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(challengeToken),
+		ExpiredAt: time.Now().Add(server.config.LoginChallengeDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create login challenge: %s", err)
+	}
+
+	rsp := &pb.LoginUserResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: timestamppb.New(challenge.ExpiredAt),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes every email to out instead of sending it. It is meant for
+// local development, where the verification links can be read off the log.
+type LogMailer struct {
+	sender Sender
+	mu     sync.Mutex
+	out    io.Writer
+}
+
+func NewLogMailer(sender Sender, out io.Writer) *LogMailer {
+	return &LogMailer{
+		sender: sender,
+		out:    out,
+	}
+}
+
+func (mailer *LogMailer) SendEmail(ctx context.Context, email Email) error {
+	msg, err := email.message(mailer.sender, time.Now())
+	if err != nil {
+		return err
+	}
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	_, err = fmt.Fprintf(mailer.out, "%s\r\n\r\n", msg)
+	return err
+}
+
+// FileMailer saves every email as an .eml file in a directory instead of
+// sending it, so that tests and local setups can open what was sent.
+type FileMailer struct {
+	sender Sender
+	dir    string
+}
+
+func NewFileMailer(sender Sender, dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create mail directory: %w", err)
+	}
+	return &FileMailer{
+		sender: sender,
+		dir:    dir,
+	}, nil
+}
+
+func (mailer *FileMailer) SendEmail(ctx context.Context, email Email) error {
+	now := time.Now()
+	msg, err := email.message(mailer.sender, now)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(mailer.dir, fmt.Sprintf("%d-*.eml", now.UnixNano()))
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(msg); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
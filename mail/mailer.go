@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+var ErrInvalidEmail = errors.New("invalid email")
+
+// Mailer sends emails to users.
+type Mailer interface {
+	SendEmail(ctx context.Context, email Email) error
+}
+
+// Sender is the From address of every email a Mailer sends.
+type Sender struct {
+	Name    string
+	Address string
+}
+
+type Email struct {
+	To      []string
+	Subject string
+	// Content is the HTML body of the email.
+	Content string
+}
+
+// recipients returns the bare addresses of email.To.
+func (email Email) recipients() ([]string, error) {
+	if len(email.To) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrInvalidEmail)
+	}
+
+	addresses := make([]string, len(email.To))
+	for i, to := range email.To {
+		address, err := netmail.ParseAddress(to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: recipient %q: %s", ErrInvalidEmail, to, err)
+		}
+		addresses[i] = address.Address
+	}
+	return addresses, nil
+}
+
+// message renders email as an RFC 5322 message sent by sender at date.
+func (email Email) message(sender Sender, date time.Time) ([]byte, error) {
+	to, err := email.recipients()
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(email.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: subject contains a line break", ErrInvalidEmail)
+	}
+
+	from := netmail.Address{Name: sender.Name, Address: sender.Address}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(email.Content)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSender = Sender{Name: "Simple Bank", Address: "no-reply@simplebank.test"}
+
+func TestMessage(t *testing.T) {
+	email := Email{
+		To:      []string{"Alice <alice@example.com>", "bob@example.com"},
+		Subject: "Welcome to Simple Bank",
+		Content: `<a href="https://simplebank.test/verify?code=abc">verify</a>`,
+	}
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	msg, err := email.message(testSender, date)
+	require.NoError(t, err)
+
+	text := string(msg)
+	require.Contains(t, text, "From: \"Simple Bank\" <no-reply@simplebank.test>\r\n")
+	require.Contains(t, text, "To: alice@example.com, bob@example.com\r\n")
+	require.Contains(t, text, "Subject: Welcome to Simple Bank\r\n")
+	require.Contains(t, text, "Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n")
+	require.Contains(t, text, "Content-Type: text/html; charset=UTF-8\r\n")
+	require.Contains(t, text, "\r\n\r\n<a href=3D\"https://simplebank.test/verify?code=3Dabc\">verify</a>")
+}
+
+func TestMessageInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		email Email
+	}{
+		{
+			name:  "NoRecipients",
+			email: Email{Subject: "hi"},
+		},
+		{
+			name:  "InvalidRecipient",
+			email: Email{To: []string{"not an address"}, Subject: "hi"},
+		},
+		{
+			name:  "HeaderInjection",
+			email: Email{To: []string{"alice@example.com"}, Subject: "hi\r\nBcc: eve@example.com"},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := testCase.email.message(testSender, time.Now())
+			require.ErrorIs(t, err, ErrInvalidEmail)
+		})
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := NewLogMailer(testSender, &out)
+
+	err := mailer.SendEmail(context.Background(), Email{
+		To:      []string{"alice@example.com"},
+		Subject: "hello",
+		Content: "<p>hi</p>",
+	})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "To: alice@example.com\r\n")
+	require.Contains(t, out.String(), "<p>hi</p>")
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFileMailer(testSender, dir)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = mailer.SendEmail(context.Background(), Email{
+			To:      []string{"alice@example.com"},
+			Subject: "hello",
+			Content: "<p>hi</p>",
+		})
+		require.NoError(t, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	msg, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(msg), "Subject: hello\r\n")
+}
@@ -0,0 +1,97 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer sends emails through an SMTP relay, upgrading the connection
+// with STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	sender   Sender
+	address  string
+	username string
+	password string
+}
+
+// NewSMTPMailer creates a mailer for the relay at address (host:port).
+// Authentication is skipped when username is empty.
+func NewSMTPMailer(sender Sender, address string, username string, password string) *SMTPMailer {
+	return &SMTPMailer{
+		sender:   sender,
+		address:  address,
+		username: username,
+		password: password,
+	}
+}
+
+func (mailer *SMTPMailer) SendEmail(ctx context.Context, email Email) error {
+	to, err := email.recipients()
+	if err != nil {
+		return err
+	}
+	msg, err := email.message(mailer.sender, time.Now())
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(mailer.address)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address %q: %w", mailer.address, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.address)
+	if err != nil {
+		return fmt.Errorf("cannot connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("cannot start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("cannot start tls: %w", err)
+		}
+	}
+
+	if mailer.username != "" {
+		auth := smtp.PlainAuth("", mailer.username, mailer.password, host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("cannot authenticate to smtp server: %w", err)
+		}
+	}
+
+	if err := client.Mail(mailer.sender.Address); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := client.Rcpt(address); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
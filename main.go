@@ -6,12 +6,17 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
 
 	"github.com/HzTTT/simple_bank/api"
 	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/fx"
 	"github.com/HzTTT/simple_bank/gapi"
+	"github.com/HzTTT/simple_bank/mail"
 	"github.com/HzTTT/simple_bank/pb"
+	"github.com/HzTTT/simple_bank/scheduler"
 	"github.com/HzTTT/simple_bank/util"
+	"github.com/HzTTT/simple_bank/worker"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -29,18 +34,59 @@ func main() {
 		log.Fatal("cannot connect to db:", err)
 	}
 	store := db.NewStore(conn)
+	go runTransferScheduler(store, config)
+	go runJobWorker(store, config)
 	go runGatewayServer(store,config)
 	runGrpcServer(store, config)
 
 }
 
+func runTransferScheduler(store db.Store, config util.Config) {
+	transferScheduler := scheduler.New(store, fx.NewDBRateProvider(store), config.ScheduledTransferPollInterval)
+
+	log.Printf("start transfer scheduler, polling every %s", config.ScheduledTransferPollInterval)
+	transferScheduler.Start(context.Background())
+}
+
+func runJobWorker(store db.Store, config util.Config) {
+	runner := worker.New(store, config.WorkerConcurrency, config.WorkerPollInterval)
+	mailer, err := newMailer(config)
+	if err != nil {
+		log.Fatal("cannot create mailer:", err)
+	}
+	worker.Handle(runner, worker.TaskSendWelcomeEmail, worker.NewSendWelcomeEmailHandler(store, mailer, config.VerifyEmailURL))
+	worker.Handle(runner, worker.TaskSendVerifyEmail, worker.NewSendVerifyEmailHandler(store, mailer, config.VerifyEmailURL))
+	worker.Handle(runner, worker.TaskSendPasswordResetEmail, worker.NewSendPasswordResetEmailHandler(store, mailer, config.ResetPasswordURL))
+	worker.Handle(runner, worker.TaskSendEmailChangedEmail, worker.NewSendEmailChangedEmailHandler(store, mailer))
+
+	log.Printf("start job worker with %d workers", config.WorkerConcurrency)
+	runner.Start(context.Background())
+}
+
+// newMailer sends through SMTP when a relay is configured. Otherwise emails
+// are saved to the outbox directory, or written to stdout if there is none.
+func newMailer(config util.Config) (mail.Mailer, error) {
+	sender := mail.Sender{Name: config.EmailSenderName, Address: config.EmailSenderAddress}
+	switch {
+	case config.SMTPAddress != "":
+		return mail.NewSMTPMailer(sender, config.SMTPAddress, config.SMTPUsername, config.SMTPPassword), nil
+	case config.MailOutboxDir != "":
+		return mail.NewFileMailer(sender, config.MailOutboxDir)
+	default:
+		return mail.NewLogMailer(sender, os.Stdout), nil
+	}
+}
+
 func runGrpcServer(store db.Store, config util.Config) {
 	server, err := gapi.NewServer(config, store)
 	if err != nil {
 		log.Fatal("cannot create server:", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(server.UnaryAuthInterceptor),
+		grpc.StreamInterceptor(server.StreamAuthInterceptor),
+	)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
@@ -62,18 +108,20 @@ func runGatewayServer(store db.Store, config util.Config) {
 		log.Fatal("cannot create server:", err)
 	}
 
-	grpcMux := runtime.NewServeMux()
+	grpcMux := runtime.NewServeMux(runtime.WithErrorHandler(gapi.GatewayErrorHandler))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
+	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, gapi.NewGatewayServer(server))
 	if err != nil {
 		log.Fatal("cannot register handler server:",err)
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", grpcMux)
+	mux.Handle(gapi.JWKSPath, server.JWKSHandler())
+	mux.Handle(gapi.OAuthTokenPath, server.OAuthTokenHandler())
 
 	listener, err := net.Listen("tcp", config.HTTPServerAddress)
 	if err != nil {
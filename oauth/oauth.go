@@ -0,0 +1,277 @@
+// Package oauth lets partner services get access tokens with the OAuth 2.0
+// client credentials grant of RFC 6749. Clients are registered in the
+// oauth_clients table and act on the accounts of the user they belong to,
+// limited to the scopes they were registered with.
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/policy"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+)
+
+// TokenPath is where both servers serve the token endpoint.
+const TokenPath = "/oauth/token"
+
+// GrantTypeClientCredentials is the only grant type the token endpoint supports.
+const GrantTypeClientCredentials = "client_credentials"
+
+const (
+	clientIDSize     = 12
+	clientSecretSize = 32
+)
+
+// ErrClientDisabled is returned for tokens of clients disabled since.
+var ErrClientDisabled = errors.New("OAuth client is disabled")
+
+// Error codes of the token endpoint, see RFC 6749 section 5.2.
+const (
+	ErrorInvalidRequest       = "invalid_request"
+	ErrorInvalidClient        = "invalid_client"
+	ErrorUnsupportedGrantType = "unsupported_grant_type"
+	ErrorInvalidScope         = "invalid_scope"
+	ErrorServerError          = "server_error"
+)
+
+// Error is an error response of the token endpoint.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (err *Error) Error() string {
+	if err.Description == "" {
+		return err.Code
+	}
+	return fmt.Sprintf("%s: %s", err.Code, err.Description)
+}
+
+// status returns the HTTP status of err. Clients that authenticated with
+// HTTP Basic are told to retry with other credentials.
+func (err *Error) status(basicAuth bool) int {
+	switch {
+	case err.Code == ErrorServerError:
+		return http.StatusInternalServerError
+	case err.Code == ErrorInvalidClient && basicAuth:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// TokenResponse is a successful response of the token endpoint. Client
+// credentials grants get no refresh token; clients ask for a new access
+// token instead.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// NewClientCredentials returns a client ID, its secret, and the hash to store
+// for the secret. The secret itself is shown once and never stored.
+func NewClientCredentials() (clientID string, secret string, hash string, err error) {
+	clientID, err = util.RandomSecret(clientIDSize)
+	if err != nil {
+		return "", "", "", err
+	}
+	secret, err = util.RandomSecret(clientSecretSize)
+	if err != nil {
+		return "", "", "", err
+	}
+	return clientID, secret, util.HashSecret(secret), nil
+}
+
+// CheckClient returns ErrClientDisabled if the client a token was issued to
+// has been disabled, so that disabling a client revokes its tokens too.
+// Other errors are database failures.
+func CheckClient(ctx context.Context, store db.Querier, clientID string) error {
+	client, err := store.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrClientDisabled
+		}
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.IsDisabled {
+		return ErrClientDisabled
+	}
+	return nil
+}
+
+// Issuer issues access tokens to registered clients.
+type Issuer struct {
+	store      db.Querier
+	tokenMaker token.Maker
+	duration   time.Duration
+}
+
+// NewIssuer creates an issuer whose tokens last for duration.
+func NewIssuer(store db.Querier, tokenMaker token.Maker, duration time.Duration) *Issuer {
+	return &Issuer{
+		store:      store,
+		tokenMaker: tokenMaker,
+		duration:   duration,
+	}
+}
+
+// Issue authenticates a client and issues it a token limited to scope, a
+// space separated list of scopes. An empty scope asks for every scope the
+// client was registered with. Errors other than *Error are database failures.
+func (issuer *Issuer) Issue(ctx context.Context, clientID string, clientSecret string, scope string) (*TokenResponse, error) {
+	client, err := issuer.store.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &Error{Code: ErrorInvalidClient, Description: "unknown client"}
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+
+	if !util.CheckSecret(clientSecret, client.SecretHash) {
+		return nil, &Error{Code: ErrorInvalidClient, Description: "incorrect client secret"}
+	}
+	if client.IsDisabled {
+		return nil, &Error{Code: ErrorInvalidClient, Description: "client is disabled"}
+	}
+
+	scopes, err := grantScopes(client.Scopes, strings.Fields(scope))
+	if err != nil {
+		return nil, err
+	}
+
+	// whatever the role of its user, a client only gets what its scopes and
+	// the accounts its user owns grant
+	accessToken, _, err := issuer.tokenMaker.CreateToken(client.Username, util.DepositorRole, issuer.duration,
+		token.WithScopes(scopes...),
+		token.WithClientID(client.ClientID),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	rsp := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(issuer.duration.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}
+	return rsp, nil
+}
+
+// grantScopes returns the scopes to grant for a request of requested. A
+// client without scopes is never granted any, as its tokens would be as
+// good as those of its user.
+func grantScopes(allowed []string, requested []string) ([]string, error) {
+	if len(allowed) == 0 {
+		return nil, &Error{Code: ErrorInvalidScope, Description: "client has no scopes"}
+	}
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	granted := make([]string, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, scope := range requested {
+		if !policy.IsSupportedScope(scope) || !contains(allowed, scope) {
+			return nil, &Error{Code: ErrorInvalidScope, Description: fmt.Sprintf("scope %s is not allowed", scope)}
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			granted = append(granted, scope)
+		}
+	}
+	return granted, nil
+}
+
+func contains(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves the token endpoint. Clients authenticate with HTTP Basic
+// or with client_id and client_secret in the form body.
+func (issuer *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientID, clientSecret, basicAuth := r.BasicAuth()
+	rsp, err := issuer.serve(r, clientID, clientSecret, basicAuth)
+	if err != nil {
+		var oauthErr *Error
+		if !errors.As(err, &oauthErr) {
+			oauthErr = &Error{Code: ErrorServerError, Description: err.Error()}
+		}
+		if oauthErr.Code == ErrorInvalidClient && basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="simple_bank"`)
+		}
+		writeJSON(w, oauthErr.status(basicAuth), oauthErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, rsp)
+}
+
+func (issuer *Issuer) serve(r *http.Request, clientID string, clientSecret string, basicAuth bool) (*TokenResponse, error) {
+	// only the body counts, so that secrets don't end up in access logs
+	if err := r.ParseForm(); err != nil {
+		return nil, &Error{Code: ErrorInvalidRequest, Description: err.Error()}
+	}
+	form := r.PostForm
+
+	if basicAuth {
+		if form.Has("client_secret") {
+			return nil, &Error{Code: ErrorInvalidRequest, Description: "client authenticated more than once"}
+		}
+		// credentials are form encoded before being put into the header
+		var err error
+		if clientID, err = url.QueryUnescape(clientID); err != nil {
+			return nil, &Error{Code: ErrorInvalidClient, Description: "malformed client id"}
+		}
+		if clientSecret, err = url.QueryUnescape(clientSecret); err != nil {
+			return nil, &Error{Code: ErrorInvalidClient, Description: "malformed client secret"}
+		}
+	} else {
+		clientID = form.Get("client_id")
+		clientSecret = form.Get("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, &Error{Code: ErrorInvalidClient, Description: "client credentials are required"}
+	}
+
+	grantType := form.Get("grant_type")
+	if grantType == "" {
+		return nil, &Error{Code: ErrorInvalidRequest, Description: "grant_type is required"}
+	}
+	if grantType != GrantTypeClientCredentials {
+		return nil, &Error{Code: ErrorUnsupportedGrantType, Description: fmt.Sprintf("grant type %s is not supported", grantType)}
+	}
+
+	return issuer.Issue(r.Context(), clientID, clientSecret, form.Get("scope"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	// tokens must not be cached, see RFC 6749 section 5.1
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
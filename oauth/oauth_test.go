@@ -0,0 +1,342 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomClient(t *testing.T) (db.OauthClient, string) {
+	clientID, secret, hash, err := NewClientCredentials()
+	require.NoError(t, err)
+
+	client := db.OauthClient{
+		ClientID:   clientID,
+		SecretHash: hash,
+		Name:       util.RandomString(6),
+		Username:   util.RandOwner(),
+		Scopes:     []string{"accounts:read", "transfers:read"},
+		CreatedAt:  time.Now(),
+	}
+	return client, secret
+}
+
+func TestGrantScopes(t *testing.T) {
+	allowed := []string{"accounts:read", "transfers:read"}
+
+	granted, err := grantScopes(allowed, nil)
+	require.NoError(t, err)
+	require.Equal(t, allowed, granted)
+
+	granted, err = grantScopes(allowed, []string{"transfers:read", "transfers:read"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"transfers:read"}, granted)
+
+	_, err = grantScopes(allowed, []string{"transfers:write"})
+	require.Equal(t, ErrorInvalidScope, err.(*Error).Code)
+
+	_, err = grantScopes(nil, nil)
+	require.Equal(t, ErrorInvalidScope, err.(*Error).Code)
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	client, secret := randomClient(t)
+
+	formRequest := func(form url.Values) *http.Request {
+		request := httptest.NewRequest(http.MethodPost, TokenPath, strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return request
+	}
+
+	testCases := []struct {
+		name          string
+		newRequest    func() *http.Request
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker)
+	}{
+		{
+			name: "BasicAuth",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{"grant_type": {GrantTypeClientCredentials}})
+				request.SetBasicAuth(client.ClientID, secret)
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+				var rsp TokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "Bearer", rsp.TokenType)
+				require.Equal(t, int64(time.Minute.Seconds()), rsp.ExpiresIn)
+				require.Equal(t, "accounts:read transfers:read", rsp.Scope)
+
+				payload, err := maker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, client.Username, payload.Username)
+				require.Equal(t, util.DepositorRole, payload.Role)
+				require.Equal(t, client.ClientID, payload.ClientID)
+				require.Equal(t, client.Scopes, payload.Scopes)
+			},
+		},
+		{
+			name: "FormAuthWithScope",
+			newRequest: func() *http.Request {
+				return formRequest(url.Values{
+					"grant_type":    {GrantTypeClientCredentials},
+					"client_id":     {client.ClientID},
+					"client_secret": {secret},
+					"scope":         {"transfers:read"},
+				})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp TokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				payload, err := maker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, []string{"transfers:read"}, payload.Scopes)
+			},
+		},
+		{
+			name: "ScopeNotAllowed",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{
+					"grant_type": {GrantTypeClientCredentials},
+					"scope":      {"transfers:write"},
+				})
+				request.SetBasicAuth(client.ClientID, secret)
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusBadRequest, ErrorInvalidScope)
+			},
+		},
+		{
+			name: "WrongSecret",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{"grant_type": {GrantTypeClientCredentials}})
+				request.SetBasicAuth(client.ClientID, "wrong")
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusUnauthorized, ErrorInvalidClient)
+				require.NotEmpty(t, recorder.Header().Get("WWW-Authenticate"))
+			},
+		},
+		{
+			name: "UnknownClient",
+			newRequest: func() *http.Request {
+				return formRequest(url.Values{
+					"grant_type":    {GrantTypeClientCredentials},
+					"client_id":     {"unknown"},
+					"client_secret": {secret},
+				})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq("unknown")).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusBadRequest, ErrorInvalidClient)
+			},
+		},
+		{
+			name: "DisabledClient",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{"grant_type": {GrantTypeClientCredentials}})
+				request.SetBasicAuth(client.ClientID, secret)
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				disabled := client
+				disabled.IsDisabled = true
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(disabled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusUnauthorized, ErrorInvalidClient)
+			},
+		},
+		{
+			name: "UnsupportedGrantType",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{"grant_type": {"password"}})
+				request.SetBasicAuth(client.ClientID, secret)
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusBadRequest, ErrorUnsupportedGrantType)
+			},
+		},
+		{
+			name: "MissingCredentials",
+			newRequest: func() *http.Request {
+				return formRequest(url.Values{"grant_type": {GrantTypeClientCredentials}})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusBadRequest, ErrorInvalidClient)
+			},
+		},
+		{
+			name: "SecretInQuery",
+			newRequest: func() *http.Request {
+				query := url.Values{"client_id": {client.ClientID}, "client_secret": {secret}}
+				request := httptest.NewRequest(http.MethodPost, TokenPath+"?"+query.Encode(),
+					strings.NewReader(url.Values{"grant_type": {GrantTypeClientCredentials}}.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusBadRequest, ErrorInvalidClient)
+			},
+		},
+		{
+			name: "InternalError",
+			newRequest: func() *http.Request {
+				request := formRequest(url.Values{"grant_type": {GrantTypeClientCredentials}})
+				request.SetBasicAuth(client.ClientID, secret)
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				requireError(t, recorder, http.StatusInternalServerError, ErrorServerError)
+			},
+		},
+		{
+			name: "MethodNotAllowed",
+			newRequest: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, TokenPath, nil)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, maker token.Maker) {
+				require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			maker, err := token.NewPasetoMaker(util.RandomString(32))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			NewIssuer(store, maker, time.Minute).ServeHTTP(recorder, tc.newRequest())
+			tc.checkResponse(t, recorder, maker)
+		})
+	}
+}
+
+func requireError(t *testing.T, recorder *httptest.ResponseRecorder, status int, code string) {
+	require.Equal(t, status, recorder.Code)
+
+	var rsp Error
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, code, rsp.Code)
+}
+
+func TestCheckClient(t *testing.T) {
+	client, _ := randomClient(t)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Disabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				disabled := client
+				disabled.IsDisabled = true
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(disabled, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrClientDisabled)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrClientDisabled)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(db.OauthClient{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrClientDisabled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			err := CheckClient(context.Background(), store, client.ClientID)
+			tc.checkError(t, err)
+		})
+	}
+}
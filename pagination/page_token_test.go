@@ -0,0 +1,102 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageToken(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	cursor := Cursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        util.RandomInt(1, 1000),
+	}
+
+	token := signer.Encode("accounts:alice", cursor)
+	require.NotEmpty(t, token)
+
+	decoded, err := signer.Decode("accounts:alice", token)
+	require.NoError(t, err)
+	require.Equal(t, cursor.ID, decoded.ID)
+	require.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+}
+
+func TestPageTokenWrongScope(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	token := signer.Encode("accounts:alice", Cursor{CreatedAt: time.Now(), ID: 1})
+
+	_, err = signer.Decode("accounts:bob", token)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestPageTokenTampered(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	token := signer.Encode("accounts:alice", Cursor{CreatedAt: time.Now(), ID: 1})
+	forged := signer.Encode("accounts:alice", Cursor{CreatedAt: time.Now(), ID: 2})
+
+	// splice the payload of one token with the signature of another
+	tampered := forged[:len(forged)-43] + token[len(token)-43:]
+	_, err = signer.Decode("accounts:alice", tampered)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	other, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+	_, err = other.Decode("accounts:alice", token)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	for _, invalid := range []string{"", "abc", "a.b", "!!!.???"} {
+		_, err = signer.Decode("accounts:alice", invalid)
+		require.ErrorIs(t, err, ErrInvalidPageToken)
+	}
+}
+
+func TestDecodeKeyset(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	// the first page has no cursor
+	createdAt, id, err := signer.DecodeKeyset("accounts:alice", "")
+	require.NoError(t, err)
+	require.False(t, createdAt.Valid)
+	require.False(t, id.Valid)
+
+	cursor := Cursor{CreatedAt: time.Now().UTC().Truncate(time.Microsecond), ID: 7}
+	createdAt, id, err = signer.DecodeKeyset("accounts:alice", signer.Encode("accounts:alice", cursor))
+	require.NoError(t, err)
+	require.True(t, createdAt.Valid)
+	require.True(t, cursor.CreatedAt.Equal(createdAt.Time))
+	require.Equal(t, cursor.ID, id.Int64)
+
+	_, _, err = signer.DecodeKeyset("accounts:bob", signer.Encode("accounts:alice", cursor))
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestNextPage(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	now := time.Now()
+	rows := []int64{1, 2, 3, 4, 5, 6}
+	cursor := func(id int64) Cursor { return Cursor{CreatedAt: now, ID: id} }
+
+	page, token := NextPage(signer, "scope", rows, 5, cursor)
+	require.Len(t, page, 5)
+	require.NotEmpty(t, token)
+
+	decoded, err := signer.Decode("scope", token)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), decoded.ID)
+
+	page, token = NextPage(signer, "scope", rows[:5], 5, cursor)
+	require.Len(t, page, 5)
+	require.Empty(t, token)
+}
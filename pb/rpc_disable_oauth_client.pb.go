@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.25.0
+// source: rpc_disable_oauth_client.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DisableOAuthClientRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClientId string `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+}
+
+func (x *DisableOAuthClientRequest) Reset() {
+	*x = DisableOAuthClientRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_disable_oauth_client_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DisableOAuthClientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableOAuthClientRequest) ProtoMessage() {}
+
+func (x *DisableOAuthClientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_disable_oauth_client_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableOAuthClientRequest.ProtoReflect.Descriptor instead.
+func (*DisableOAuthClientRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_disable_oauth_client_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DisableOAuthClientRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type DisableOAuthClientResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Client *OAuthClient `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+}
+
+func (x *DisableOAuthClientResponse) Reset() {
+	*x = DisableOAuthClientResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_disable_oauth_client_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DisableOAuthClientResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableOAuthClientResponse) ProtoMessage() {}
+
+func (x *DisableOAuthClientResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_disable_oauth_client_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableOAuthClientResponse.ProtoReflect.Descriptor instead.
+func (*DisableOAuthClientResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_disable_oauth_client_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DisableOAuthClientResponse) GetClient() *OAuthClient {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+var File_rpc_disable_oauth_client_proto protoreflect.FileDescriptor
+
+var file_rpc_disable_oauth_client_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x72, 0x70, 0x63, 0x5f, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6f, 0x61,
+	0x75, 0x74, 0x68, 0x5f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x12, 0x6f, 0x61, 0x75, 0x74, 0x68, 0x5f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x38, 0x0a, 0x19, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x4f,
+	0x41, 0x75, 0x74, 0x68, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22, 0x42,
+	0x0a, 0x1a, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x4f, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x06,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x4f,
+	0x41, 0x75, 0x74, 0x68, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x48, 0x7a, 0x54, 0x54, 0x54, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x62, 0x61,
+	0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_disable_oauth_client_proto_rawDescOnce sync.Once
+	file_rpc_disable_oauth_client_proto_rawDescData = file_rpc_disable_oauth_client_proto_rawDesc
+)
+
+func file_rpc_disable_oauth_client_proto_rawDescGZIP() []byte {
+	file_rpc_disable_oauth_client_proto_rawDescOnce.Do(func() {
+		file_rpc_disable_oauth_client_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_disable_oauth_client_proto_rawDescData)
+	})
+	return file_rpc_disable_oauth_client_proto_rawDescData
+}
+
+var file_rpc_disable_oauth_client_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_disable_oauth_client_proto_goTypes = []interface{}{
+	(*DisableOAuthClientRequest)(nil),  // 0: DisableOAuthClientRequest
+	(*DisableOAuthClientResponse)(nil), // 1: DisableOAuthClientResponse
+	(*OAuthClient)(nil),                // 2: OAuthClient
+}
+var file_rpc_disable_oauth_client_proto_depIdxs = []int32{
+	2, // 0: DisableOAuthClientResponse.client:type_name -> OAuthClient
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_rpc_disable_oauth_client_proto_init() }
+func file_rpc_disable_oauth_client_proto_init() {
+	if File_rpc_disable_oauth_client_proto != nil {
+		return
+	}
+	file_oauth_client_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_disable_oauth_client_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DisableOAuthClientRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_disable_oauth_client_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DisableOAuthClientResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_disable_oauth_client_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_disable_oauth_client_proto_goTypes,
+		DependencyIndexes: file_rpc_disable_oauth_client_proto_depIdxs,
+		MessageInfos:      file_rpc_disable_oauth_client_proto_msgTypes,
+	}.Build()
+	File_rpc_disable_oauth_client_proto = out.File
+	file_rpc_disable_oauth_client_proto_rawDesc = nil
+	file_rpc_disable_oauth_client_proto_goTypes = nil
+	file_rpc_disable_oauth_client_proto_depIdxs = nil
+}
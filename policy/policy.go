@@ -0,0 +1,98 @@
+// Package policy decides who may do what. Users may always act on what they
+// own; roles grant the same actions on what other users own. Both the REST
+// and the gRPC servers ask this package instead of comparing usernames.
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+)
+
+// ErrPermissionDenied is returned when a caller may not take an action.
+var ErrPermissionDenied = errors.New("permission denied")
+
+type Action string
+
+const (
+	// ViewAccount reads an account together with its entries and transfers.
+	ViewAccount Action = "view account"
+	// OperateAccount moves money out of an account, schedules transfers from
+	// it, and closes it.
+	OperateAccount Action = "operate account"
+	// FreezeAccount stops money from moving in or out of an account.
+	FreezeAccount Action = "freeze account"
+	// UnfreezeAccount lifts a freeze. Owners may not lift one the bank
+	// imposed, so it is granted by role alone.
+	UnfreezeAccount Action = "unfreeze account"
+	// ReverseTransfer reverses a transfer into an account. The money goes
+	// back out of that account, so it is authorized against its owner.
+	ReverseTransfer Action = "reverse transfer"
+	// ManageSession lists and revokes the sessions of a user.
+	ManageSession Action = "manage session"
+	// UpdateUser changes the profile and password of a user.
+	UpdateUser Action = "update user"
+	// ManageRoles assigns roles. Nobody owns a role, so it is granted by
+	// role alone.
+	ManageRoles Action = "manage roles"
+	// SetOverdraftLimit changes how far below zero an account may go. Owners
+	// may not raise their own limit, so it is granted by role alone.
+	SetOverdraftLimit Action = "set overdraft limit"
+	// ManageOAuthClients registers and disables the OAuth clients of partner
+	// services. Like roles, it is granted by role alone.
+	ManageOAuthClients Action = "manage OAuth clients"
+)
+
+// grants lists the actions each role may take on resources of other users.
+var grants = map[string]map[Action]bool{
+	util.DepositorRole: {},
+	util.BankerRole: {
+		ViewAccount:       true,
+		FreezeAccount:     true,
+		UnfreezeAccount:   true,
+		ReverseTransfer:   true,
+		SetOverdraftLimit: true,
+	},
+	util.AdminRole: {
+		ViewAccount:        true,
+		FreezeAccount:      true,
+		UnfreezeAccount:    true,
+		ReverseTransfer:    true,
+		UpdateUser:         true,
+		ManageRoles:        true,
+		SetOverdraftLimit:  true,
+		ManageOAuthClients: true,
+	},
+}
+
+// AccountStatusAction returns the action that moving an account of owner to
+// status takes, and the owner to authorize it against. Owners can't lift a
+// freeze, or they could undo one imposed by the bank.
+func AccountStatusAction(status string, owner string) (Action, string) {
+	switch status {
+	case db.AccountStatusFrozen:
+		return FreezeAccount, owner
+	case db.AccountStatusActive:
+		return UnfreezeAccount, ""
+	default:
+		return OperateAccount, owner
+	}
+}
+
+// Authorize checks that the holder of payload may take action on a resource
+// of owner. Pass an empty owner for actions that are not tied to a user.
+func Authorize(payload *token.Payload, action Action, owner string) error {
+	if owner != "" && owner == payload.Username {
+		return nil
+	}
+	if grants[payload.Role][action] {
+		return nil
+	}
+	if owner == "" {
+		return fmt.Errorf("%w: %s can't %s", ErrPermissionDenied, payload.Username, action)
+	}
+	return fmt.Errorf("%w: %s can't %s of %s", ErrPermissionDenied, payload.Username, action, owner)
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"testing"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	owner := util.RandOwner()
+	other := util.RandOwner()
+
+	testCases := []struct {
+		name    string
+		role    string
+		action  Action
+		allowed bool
+	}{
+		{"DepositorViewAccount", util.DepositorRole, ViewAccount, false},
+		{"DepositorReverseTransfer", util.DepositorRole, ReverseTransfer, false},
+		{"BankerViewAccount", util.BankerRole, ViewAccount, true},
+		{"BankerReverseTransfer", util.BankerRole, ReverseTransfer, true},
+		{"BankerOperateAccount", util.BankerRole, OperateAccount, false},
+		{"BankerFreezeAccount", util.BankerRole, FreezeAccount, true},
+		{"BankerUnfreezeAccount", util.BankerRole, UnfreezeAccount, true},
+		{"DepositorUnfreezeAccount", util.DepositorRole, UnfreezeAccount, false},
+		{"BankerUpdateUser", util.BankerRole, UpdateUser, false},
+		{"BankerSetOverdraftLimit", util.BankerRole, SetOverdraftLimit, true},
+		{"DepositorSetOverdraftLimit", util.DepositorRole, SetOverdraftLimit, false},
+		{"AdminViewAccount", util.AdminRole, ViewAccount, true},
+		{"AdminUpdateUser", util.AdminRole, UpdateUser, true},
+		{"AdminOperateAccount", util.AdminRole, OperateAccount, false},
+		{"AdminManageSession", util.AdminRole, ManageSession, false},
+		{"AdminManageOAuthClients", util.AdminRole, ManageOAuthClients, true},
+		{"BankerManageOAuthClients", util.BankerRole, ManageOAuthClients, false},
+		{"UnknownRole", "superuser", ViewAccount, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := &token.Payload{Username: other, Role: tc.role}
+
+			err := Authorize(payload, tc.action, owner)
+			if tc.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrPermissionDenied)
+			}
+
+			// owners may always act on what they own
+			payload.Username = owner
+			require.NoError(t, Authorize(payload, tc.action, owner))
+		})
+	}
+}
+
+func TestAuthorizeWithoutOwner(t *testing.T) {
+	admin := &token.Payload{Username: util.RandOwner(), Role: util.AdminRole}
+	require.NoError(t, Authorize(admin, ManageRoles, ""))
+
+	banker := &token.Payload{Username: util.RandOwner(), Role: util.BankerRole}
+	require.ErrorIs(t, Authorize(banker, ManageRoles, ""), ErrPermissionDenied)
+
+	// an empty username doesn't own resources without an owner
+	nobody := &token.Payload{Role: util.DepositorRole}
+	require.ErrorIs(t, Authorize(nobody, ManageRoles, ""), ErrPermissionDenied)
+}
+
+func TestAccountStatusAction(t *testing.T) {
+	owner := util.RandOwner()
+	depositor := &token.Payload{Username: owner, Role: util.DepositorRole}
+
+	action, actionOwner := AccountStatusAction(db.AccountStatusFrozen, owner)
+	require.Equal(t, FreezeAccount, action)
+	require.NoError(t, Authorize(depositor, action, actionOwner))
+
+	action, actionOwner = AccountStatusAction(db.AccountStatusClosed, owner)
+	require.Equal(t, OperateAccount, action)
+	require.NoError(t, Authorize(depositor, action, actionOwner))
+
+	// owners can't lift a freeze, bankers can
+	action, actionOwner = AccountStatusAction(db.AccountStatusActive, owner)
+	require.Equal(t, UnfreezeAccount, action)
+	require.ErrorIs(t, Authorize(depositor, action, actionOwner), ErrPermissionDenied)
+	banker := &token.Payload{Username: util.RandOwner(), Role: util.BankerRole}
+	require.NoError(t, Authorize(banker, action, actionOwner))
+}
+
+func TestRequireScope(t *testing.T) {
+	testCases := []struct {
+		name    string
+		scopes  []string
+		scope   Scope
+		allowed bool
+	}{
+		{"Unscoped", nil, ScopeAccountsWrite, true},
+		{"UnscopedSessionOnly", nil, "", true},
+		{"Granted", []string{"accounts:read", "transfers:write"}, ScopeTransfersWrite, true},
+		{"Missing", []string{"accounts:read"}, ScopeAccountsWrite, false},
+		{"SessionOnly", []string{"accounts:read"}, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := &token.Payload{Username: util.RandOwner(), Scopes: tc.scopes}
+
+			err := RequireScope(payload, tc.scope)
+			if tc.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrPermissionDenied)
+			}
+		})
+	}
+}
+
+func TestIsSupportedScope(t *testing.T) {
+	require.True(t, IsSupportedScope("accounts:read"))
+	require.True(t, IsSupportedScope("transfers:write"))
+	require.False(t, IsSupportedScope("users:write"))
+	require.False(t, IsSupportedScope(""))
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/HzTTT/simple_bank/token"
+)
+
+// Scope limits what a token may be used for. Tokens from logging in carry
+// no scopes and may do anything their user may; API keys always carry some.
+type Scope string
+
+const (
+	// ScopeAccountsRead reads accounts and their entries.
+	ScopeAccountsRead Scope = "accounts:read"
+	// ScopeAccountsWrite opens, freezes, unfreezes and closes accounts.
+	ScopeAccountsWrite Scope = "accounts:write"
+	// ScopeTransfersRead reads transfers and scheduled transfers.
+	ScopeTransfersRead Scope = "transfers:read"
+	// ScopeTransfersWrite makes, reverses and schedules transfers.
+	ScopeTransfersWrite Scope = "transfers:write"
+)
+
+var scopes = map[Scope]bool{
+	ScopeAccountsRead:   true,
+	ScopeAccountsWrite:  true,
+	ScopeTransfersRead:  true,
+	ScopeTransfersWrite: true,
+}
+
+// IsSupportedScope reports whether scope is one that tokens may carry.
+func IsSupportedScope(scope string) bool {
+	return scopes[Scope(scope)]
+}
+
+// RequireScope checks that payload may be used where scope is needed. Pass an
+// empty scope where only unscoped tokens are accepted, such as for managing
+// the user or its API keys.
+func RequireScope(payload *token.Payload, scope Scope) error {
+	if len(payload.Scopes) == 0 {
+		return nil
+	}
+	for _, granted := range payload.Scopes {
+		if scope != "" && Scope(granted) == scope {
+			return nil
+		}
+	}
+	if scope == "" {
+		return fmt.Errorf("%w: scoped tokens can't be used here", ErrPermissionDenied)
+	}
+	return fmt.Errorf("%w: token lacks the %s scope", ErrPermissionDenied, scope)
+}
@@ -0,0 +1,76 @@
+// Package refresh checks refresh tokens against the sessions they were
+// issued with. Both the REST and the gRPC servers use it, so a refresh token
+// that is good for one is good for the other.
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+)
+
+var (
+	// ErrInvalidToken wraps the reasons a refresh token can't be used.
+	ErrInvalidToken = errors.New("invalid refresh token")
+	// ErrSessionNotFound is returned for refresh tokens without a session.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrReused is returned for refresh tokens that were already traded in.
+	ErrReused = errors.New("refresh token was already used")
+)
+
+// Verify returns the session of refreshToken if it can still be used, that
+// is if it is neither revoked, retired nor expired. Presenting a retired
+// refresh token blocks its whole session family, see BlockReused. Errors
+// other than ErrInvalidToken and ErrSessionNotFound are database failures.
+func Verify(ctx context.Context, store db.Querier, tokenMaker token.Maker, refreshToken string) (db.Session, error) {
+	refreshPayload, err := tokenMaker.VerifyToken(refreshToken)
+	if err != nil {
+		return db.Session{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	session, err := store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return session, ErrSessionNotFound
+		}
+		return session, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.IsBlocked {
+		return session, fmt.Errorf("%w: blocked session", ErrInvalidToken)
+	}
+	if session.Username != refreshPayload.Username {
+		return session, fmt.Errorf("%w: incorrect session user", ErrInvalidToken)
+	}
+	if session.RefreshToken != refreshToken {
+		return session, fmt.Errorf("%w: mismatched session token", ErrInvalidToken)
+	}
+	if session.IsRetired {
+		return session, BlockReused(ctx, store, session)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return session, fmt.Errorf("%w: expired session", ErrInvalidToken)
+	}
+
+	return session, nil
+}
+
+// BlockReused handles a refresh token that was already traded in. Either the
+// token was stolen or the thief already rotated it, so every session
+// descending from the same login is blocked. It returns ErrReused, wrapped
+// in ErrInvalidToken, unless blocking fails.
+func BlockReused(ctx context.Context, store db.Querier, session db.Session) error {
+	err := store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		return fmt.Errorf("failed to block sessions: %w", err)
+	}
+
+	log.Printf("refresh token reuse detected for user %s, blocked session family %s", session.Username, session.FamilyID)
+	return fmt.Errorf("%w: %w", ErrInvalidToken, ErrReused)
+}
@@ -0,0 +1,150 @@
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/token"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandOwner()
+	refreshToken, payload, err := tokenMaker.CreateToken(username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	session := db.Session{
+		ID:           payload.ID,
+		Username:     username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+		FamilyID:     uuid.New(),
+	}
+
+	testCases := []struct {
+		name         string
+		refreshToken string
+		buildStubs   func(store *mockdb.MockStore)
+		checkError   func(t *testing.T, err error)
+	}{
+		{
+			name:         "OK",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:         "InvalidToken",
+			refreshToken: "invalid",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidToken)
+			},
+		},
+		{
+			name:         "SessionNotFound",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSessionNotFound)
+			},
+		},
+		{
+			name:         "BlockedSession",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				blocked := session
+				blocked.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(blocked, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidToken)
+			},
+		},
+		{
+			name:         "ReusedToken",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				retired := session
+				retired.IsRetired = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(retired, nil)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidToken)
+				require.ErrorIs(t, err, ErrReused)
+			},
+		},
+		{
+			name:         "BlockFamilyError",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				retired := session
+				retired.IsRetired = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(retired, nil)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrInvalidToken)
+			},
+		},
+		{
+			name:         "ExpiredSession",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := session
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(expired, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidToken)
+			},
+		},
+		{
+			name:         "InternalError",
+			refreshToken: refreshToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrInvalidToken)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			got, err := Verify(context.Background(), store, tokenMaker, tc.refreshToken)
+			tc.checkError(t, err)
+			if err == nil {
+				require.Equal(t, session.ID, got.ID)
+			}
+		})
+	}
+}
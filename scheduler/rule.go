@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/robfig/cron/v3"
+)
+
+// MinInterval is the shortest interval a schedule may repeat at.
+const MinInterval = time.Minute
+
+var ErrInvalidRule = errors.New("invalid schedule rule")
+
+// Rule decides when a scheduled transfer runs.
+type Rule interface {
+	// Next returns the first occurrence strictly after t.
+	Next(t time.Time) time.Time
+}
+
+type intervalRule struct {
+	start    time.Time
+	interval time.Duration
+}
+
+func (rule intervalRule) Next(t time.Time) time.Time {
+	if t.Before(rule.start) {
+		return rule.start
+	}
+	// stay in phase with start so that missed runs do not shift the schedule
+	n := t.Sub(rule.start)/rule.interval + 1
+	return rule.start.Add(n * rule.interval)
+}
+
+type cronRule struct {
+	schedule cron.Schedule
+	start    time.Time
+}
+
+func (rule cronRule) Next(t time.Time) time.Time {
+	if t.Before(rule.start) {
+		t = rule.start.Add(-time.Second)
+	}
+	return rule.schedule.Next(t)
+}
+
+// ParseRule builds a rule from a standard five field cron expression or a
+// repeat interval; exactly one of them must be set. Occurrences start at start.
+func ParseRule(cronExpression string, interval time.Duration, start time.Time) (Rule, error) {
+	switch {
+	case cronExpression != "" && interval != 0:
+		return nil, fmt.Errorf("%w: set either a cron expression or an interval, not both", ErrInvalidRule)
+	case cronExpression != "":
+		schedule, err := cron.ParseStandard(cronExpression)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRule, err)
+		}
+		return cronRule{schedule: schedule, start: start}, nil
+	case interval != 0:
+		if interval < MinInterval {
+			return nil, fmt.Errorf("%w: interval must be at least %s", ErrInvalidRule, MinInterval)
+		}
+		return intervalRule{start: start, interval: interval}, nil
+	default:
+		return nil, fmt.Errorf("%w: a cron expression or an interval is required", ErrInvalidRule)
+	}
+}
+
+// RuleOf returns the rule stored on a scheduled transfer.
+func RuleOf(schedule db.ScheduledTransfer) (Rule, error) {
+	return ParseRule(
+		schedule.CronExpression.String,
+		time.Duration(schedule.IntervalSeconds.Int64)*time.Second,
+		schedule.StartAt,
+	)
+}
+
+// FirstRun returns the first occurrence of rule at or after start.
+func FirstRun(rule Rule, start time.Time) time.Time {
+	return rule.Next(start.Add(-time.Nanosecond))
+}
+
+// NextRun returns when a schedule should run after the occurrence at
+// scheduledFor has been handled at now, or false once it has finished.
+// Occurrences missed while the server was down are skipped rather than
+// replayed one after another.
+func NextRun(schedule db.ScheduledTransfer, rule Rule, scheduledFor time.Time, now time.Time) (time.Time, bool) {
+	if schedule.MaxRuns.Valid && schedule.RunCount+1 >= schedule.MaxRuns.Int32 {
+		return time.Time{}, false
+	}
+
+	after := scheduledFor
+	if now.After(after) {
+		after = now
+	}
+	next := rule.Next(after)
+
+	if schedule.EndAt.Valid && next.After(schedule.EndAt.Time) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// ResumeAt returns when a paused schedule should next run if resumed at now,
+// or false if it would never run again. Runs missed while paused are
+// skipped, not replayed.
+func ResumeAt(schedule db.ScheduledTransfer, rule Rule, now time.Time) (time.Time, bool) {
+	next := schedule.NextRunAt.Time
+	if !schedule.NextRunAt.Valid || next.Before(now) {
+		next = rule.Next(now)
+	}
+
+	if schedule.EndAt.Valid && next.After(schedule.EndAt.Time) {
+		return time.Time{}, false
+	}
+	return next, true
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalRule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	rule, err := ParseRule("", time.Hour, start)
+	require.NoError(t, err)
+
+	require.Equal(t, start, FirstRun(rule, start))
+	require.Equal(t, start, rule.Next(start.Add(-time.Minute)))
+	require.Equal(t, start.Add(time.Hour), rule.Next(start))
+	require.Equal(t, start.Add(3*time.Hour), rule.Next(start.Add(2*time.Hour+time.Minute)))
+}
+
+func TestCronRule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+
+	// rent on the first of every month at 08:00
+	rule, err := ParseRule("0 8 1 * *", 0, start)
+	require.NoError(t, err)
+
+	first := FirstRun(rule, start)
+	require.Equal(t, time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC), first)
+	require.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), rule.Next(first))
+
+	rule, err = ParseRule("30 9 * * *", 0, start)
+	require.NoError(t, err)
+	require.Equal(t, start, FirstRun(rule, start))
+}
+
+func TestParseRuleInvalid(t *testing.T) {
+	start := time.Now()
+
+	for _, tc := range []struct {
+		name     string
+		cron     string
+		interval time.Duration
+	}{
+		{"Missing", "", 0},
+		{"Both", "* * * * *", time.Hour},
+		{"BadCron", "every day", 0},
+		{"ShortInterval", "", time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRule(tc.cron, tc.interval, start)
+			require.ErrorIs(t, err, ErrInvalidRule)
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	rule, err := ParseRule("", time.Hour, start)
+	require.NoError(t, err)
+
+	schedule := db.ScheduledTransfer{StartAt: start}
+
+	next, ok := NextRun(schedule, rule, start, start)
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Hour), next)
+
+	// missed occurrences are skipped
+	next, ok = NextRun(schedule, rule, start, start.Add(5*time.Hour+time.Minute))
+	require.True(t, ok)
+	require.Equal(t, start.Add(6*time.Hour), next)
+
+	schedule.MaxRuns = sql.NullInt32{Int32: 3, Valid: true}
+	schedule.RunCount = 2
+	_, ok = NextRun(schedule, rule, start, start)
+	require.False(t, ok)
+
+	schedule.MaxRuns = sql.NullInt32{}
+	schedule.EndAt = sql.NullTime{Time: start.Add(30 * time.Minute), Valid: true}
+	_, ok = NextRun(schedule, rule, start, start)
+	require.False(t, ok)
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/fx"
+)
+
+const (
+	batchSize           = 100
+	defaultPollInterval = time.Minute
+)
+
+// Scheduler periodically runs the scheduled transfers that are due.
+type Scheduler struct {
+	store        db.Store
+	fxProvider   fx.FXRateProvider
+	pollInterval time.Duration
+}
+
+func New(store db.Store, fxProvider fx.FXRateProvider, pollInterval time.Duration) *Scheduler {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	return &Scheduler{
+		store:        store,
+		fxProvider:   fxProvider,
+		pollInterval: pollInterval,
+	}
+}
+
+// Start runs due transfers every poll interval until ctx is cancelled.
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(scheduler.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.RunDue(ctx, time.Now()); err != nil {
+			log.Printf("cannot run scheduled transfers: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs every scheduled transfer due at now, one occurrence each.
+func (scheduler *Scheduler) RunDue(ctx context.Context, now time.Time) error {
+	schedules, err := scheduler.store.ListDueScheduledTransfers(ctx, db.ListDueScheduledTransfersParams{
+		Now:       now,
+		PageLimit: batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		result, err := scheduler.run(ctx, schedule, now)
+		if errors.Is(err, db.ErrScheduledTransferNotDue) {
+			continue
+		}
+		if err != nil {
+			log.Printf("cannot run scheduled transfer [%d]: %s", schedule.ID, err)
+			continue
+		}
+		if result.Run.Status == db.ScheduledTransferRunFailed {
+			log.Printf("scheduled transfer [%d] failed: %s", schedule.ID, result.Run.FailureReason.String)
+		}
+	}
+	return nil
+}
+
+func (scheduler *Scheduler) run(ctx context.Context, schedule db.ScheduledTransfer, now time.Time) (db.RunScheduledTransferTxResult, error) {
+	arg := db.RunScheduledTransferTxParams{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.NextRunAt.Time,
+	}
+
+	rule, err := RuleOf(schedule)
+	if err != nil {
+		return db.RunScheduledTransferTxResult{}, err
+	}
+	if next, ok := NextRun(schedule, rule, arg.ScheduledFor, now); ok {
+		arg.NextRunAt = sql.NullTime{Time: next, Valid: true}
+	}
+
+	toAccount, err := scheduler.store.GetAccount(ctx, schedule.ToAccountID)
+	if err != nil {
+		return db.RunScheduledTransferTxResult{}, err
+	}
+
+	if toAccount.Currency != schedule.Currency {
+		quote, err := scheduler.fxProvider.GetQuote(ctx, schedule.Currency, toAccount.Currency)
+		switch {
+		case errors.Is(err, fx.ErrRateNotFound):
+			arg.FailureReason = err.Error()
+		case err != nil:
+			return db.RunScheduledTransferTxResult{}, err
+		default:
+			arg.ToAmount, err = quote.Convert(schedule.Amount)
+			if err != nil {
+				return db.RunScheduledTransferTxResult{}, err
+			}
+			if arg.ToAmount <= 0 {
+				arg.FailureReason = "amount is too small to convert from " + quote.From + " to " + quote.To
+			}
+			arg.ExchangeRate = quote.Rate
+			arg.RateTimestamp = quote.Timestamp
+		}
+	}
+
+	return scheduler.store.RunScheduledTransferTx(ctx, arg)
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/fx"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRateProvider map[string]fx.Quote
+
+func (provider stubRateProvider) GetQuote(ctx context.Context, from string, to string) (fx.Quote, error) {
+	quote, ok := provider[from+to]
+	if !ok {
+		return fx.Quote{}, fx.ErrRateNotFound
+	}
+	return quote, nil
+}
+
+func randomSchedule(currency string) db.ScheduledTransfer {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	return db.ScheduledTransfer{
+		ID:              util.RandomInt(1, 1000),
+		Owner:           util.RandOwner(),
+		FromAccountID:   util.RandomInt(1, 1000),
+		ToAccountID:     util.RandomInt(1001, 2000),
+		Amount:          100,
+		Currency:        currency,
+		IntervalSeconds: sql.NullInt64{Int64: 3600, Valid: true},
+		StartAt:         start,
+		NextRunAt:       sql.NullTime{Time: start, Valid: true},
+		Status:          db.ScheduledTransferStatusActive,
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	now := time.Now()
+	rateTimestamp := now.Add(-time.Minute)
+	provider := stubRateProvider{
+		"USDEUR": {From: "USD", To: "EUR", Rate: "0.9", Timestamp: rateTimestamp},
+	}
+
+	sameCurrency := randomSchedule("USD")
+	crossCurrency := randomSchedule("USD")
+	noRate := randomSchedule("USD")
+	lastRun := randomSchedule("USD")
+	lastRun.MaxRuns = sql.NullInt32{Int32: 1, Valid: true}
+	runElsewhere := randomSchedule("USD")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Eq(db.ListDueScheduledTransfersParams{Now: now, PageLimit: batchSize})).
+		Times(1).
+		Return([]db.ScheduledTransfer{sameCurrency, crossCurrency, noRate, lastRun, runElsewhere}, nil)
+
+	expectRun := func(schedule db.ScheduledTransfer, toCurrency string, arg db.RunScheduledTransferTxParams, err error) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(schedule.ToAccountID)).Times(1).
+			Return(db.Account{ID: schedule.ToAccountID, Currency: toCurrency}, nil)
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+			Return(db.RunScheduledTransferTxResult{}, err)
+	}
+
+	next := sql.NullTime{Time: sameCurrency.StartAt.Add(2 * time.Hour), Valid: true}
+	expectRun(sameCurrency, "USD", db.RunScheduledTransferTxParams{
+		ScheduledTransferID: sameCurrency.ID,
+		ScheduledFor:        sameCurrency.NextRunAt.Time,
+		NextRunAt:           next,
+	}, nil)
+	expectRun(crossCurrency, "EUR", db.RunScheduledTransferTxParams{
+		ScheduledTransferID: crossCurrency.ID,
+		ScheduledFor:        crossCurrency.NextRunAt.Time,
+		ToAmount:            90,
+		ExchangeRate:        "0.9",
+		RateTimestamp:       rateTimestamp,
+		NextRunAt:           next,
+	}, nil)
+	expectRun(noRate, "GBP", db.RunScheduledTransferTxParams{
+		ScheduledTransferID: noRate.ID,
+		ScheduledFor:        noRate.NextRunAt.Time,
+		FailureReason:       fx.ErrRateNotFound.Error(),
+		NextRunAt:           next,
+	}, nil)
+	expectRun(lastRun, "USD", db.RunScheduledTransferTxParams{
+		ScheduledTransferID: lastRun.ID,
+		ScheduledFor:        lastRun.NextRunAt.Time,
+	}, nil)
+	expectRun(runElsewhere, "USD", db.RunScheduledTransferTxParams{
+		ScheduledTransferID: runElsewhere.ID,
+		ScheduledFor:        runElsewhere.NextRunAt.Time,
+		NextRunAt:           next,
+	}, db.ErrScheduledTransferNotDue)
+
+	scheduler := New(store, provider, time.Minute)
+	err := scheduler.RunDue(context.Background(), now)
+	require.NoError(t, err)
+}
@@ -0,0 +1,194 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+)
+
+const (
+	// ScopeUsername marks failures counted against the account being logged into.
+	ScopeUsername = "username"
+	// ScopeIP marks failures counted against the client address.
+	ScopeIP = "ip"
+)
+
+// FreeFailures is how many logins to an account may fail in a row before
+// the next attempt is delayed.
+const FreeFailures = 3
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// ErrLoginFailed wraps the errors of logins that failed for wrong
+// credentials, see Failed.
+var ErrLoginFailed = errors.New("login failed")
+
+// Error is returned when a login is attempted too soon after failed ones.
+type Error struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (err *Error) Error() string {
+	if err.Locked {
+		return fmt.Sprintf("%s, login is locked, retry after %s", ErrTooManyAttempts, err.RetryAfter)
+	}
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, err.RetryAfter)
+}
+
+func (err *Error) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// Limit sets when failures start delaying the next attempt and when they lock
+// logins out. MaxFailures <= 0 never locks out.
+type Limit struct {
+	FreeFailures int32
+	MaxFailures  int32
+}
+
+// Delay returns how long to wait after the last of failures failed logins.
+// The wait doubles with every failure past the free ones, up to the lockout.
+func Delay(limit Limit, failures int32, backoffBase time.Duration, lockout time.Duration) (time.Duration, bool) {
+	if limit.MaxFailures > 0 && failures >= limit.MaxFailures {
+		return lockout, true
+	}
+	if failures < limit.FreeFailures {
+		return 0, false
+	}
+
+	delay := backoffBase
+	for i := limit.FreeFailures; i < failures && delay < lockout; i++ {
+		delay *= 2
+	}
+	if delay > lockout {
+		delay = lockout
+	}
+	return delay, false
+}
+
+// Guard keeps track of failed logins per username and per client IP.
+type Guard struct {
+	store       db.Store
+	limits      map[string]Limit
+	backoffBase time.Duration
+	lockout     time.Duration
+}
+
+// NewGuard creates a guard backed by the login_failures table. A client IP
+// gets as many free failures as an account gets before it is locked out.
+func NewGuard(store db.Store, config util.Config) *Guard {
+	return &Guard{
+		store: store,
+		limits: map[string]Limit{
+			ScopeUsername: {
+				FreeFailures: FreeFailures,
+				MaxFailures:  int32(config.LoginLockoutThreshold),
+			},
+			ScopeIP: {
+				FreeFailures: int32(config.LoginLockoutThreshold),
+				MaxFailures:  int32(config.LoginIPLockoutThreshold),
+			},
+		},
+		backoffBase: config.LoginBackoffBase,
+		lockout:     config.LoginLockoutDuration,
+	}
+}
+
+// Failed marks err as a failed login, to be counted against the username and
+// the client IP. Other errors of a login are returned as they are.
+func Failed(err error) error {
+	return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+}
+
+// Attempt runs login unless username or clientIP have to wait before trying
+// to log in again, in which case it returns an *Error. A login failing with
+// an error from Failed is recorded, a successful one forgets the failed
+// logins of username. No lock is held while login runs, so a slow password
+// check doesn't hold up other logins; attempts racing past the check are
+// still counted, as each failure is recorded with a single upsert.
+func (guard *Guard) Attempt(ctx context.Context, username string, clientIP string, login func() error) error {
+	failures, err := guard.store.GetLoginFailures(ctx, db.GetLoginFailuresParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get login failures: %w", err)
+	}
+	if err := guard.check(failures); err != nil {
+		return err
+	}
+
+	loginErr := login()
+	if errors.Is(loginErr, ErrLoginFailed) {
+		if err := guard.fail(ctx, username, clientIP); err != nil {
+			return err
+		}
+		return loginErr
+	}
+	if loginErr != nil {
+		return loginErr
+	}
+	return guard.succeed(ctx, username)
+}
+
+// check returns an *Error if any of failures has to wait before trying to
+// log in again.
+func (guard *Guard) check(failures []db.LoginFailure) error {
+	var throttled *Error
+	now := time.Now()
+	for _, failure := range failures {
+		delay, locked := Delay(guard.limits[failure.Scope], failure.Failures, guard.backoffBase, guard.lockout)
+		retryAfter := failure.LastFailedAt.Add(delay).Sub(now)
+		if retryAfter <= 0 {
+			continue
+		}
+		if throttled == nil || retryAfter > throttled.RetryAfter {
+			throttled = &Error{RetryAfter: retryAfter, Locked: locked}
+		}
+	}
+	if throttled != nil {
+		// round up, so that retrying right after the hint succeeds
+		throttled.RetryAfter = (throttled.RetryAfter + time.Second - 1).Truncate(time.Second)
+		return throttled
+	}
+	return nil
+}
+
+// fail records a failed login of username from clientIP. Failures older than
+// the lockout duration are forgotten.
+func (guard *Guard) fail(ctx context.Context, username string, clientIP string) error {
+	failures, err := guard.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Username:    username,
+		ClientIp:    clientIP,
+		ResetBefore: time.Now().Add(-guard.lockout),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	log.Printf("failed login for user %s from %s", username, clientIP)
+	for _, failure := range failures {
+		limit := guard.limits[failure.Scope]
+		if limit.MaxFailures > 0 && failure.Failures == limit.MaxFailures {
+			log.Printf("login locked for %s %s for %s", failure.Scope, failure.Key, guard.lockout)
+		}
+	}
+	return nil
+}
+
+// succeed forgets the failed logins of username. Those of the client IP are
+// kept, so that logging into an account of one's own doesn't allow guessing
+// the passwords of others.
+func (guard *Guard) succeed(ctx context.Context, username string) error {
+	err := guard.store.ClearLoginFailures(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to clear login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay(t *testing.T) {
+	limit := Limit{FreeFailures: 3, MaxFailures: 10}
+	testCases := []struct {
+		failures int32
+		delay    time.Duration
+		locked   bool
+	}{
+		{failures: 0, delay: 0},
+		{failures: 2, delay: 0},
+		{failures: 3, delay: time.Second},
+		{failures: 4, delay: 2 * time.Second},
+		{failures: 6, delay: 8 * time.Second},
+		{failures: 9, delay: time.Minute},
+		{failures: 10, delay: time.Minute, locked: true},
+		{failures: 20, delay: time.Minute, locked: true},
+	}
+
+	for _, tc := range testCases {
+		delay, locked := Delay(limit, tc.failures, time.Second, time.Minute)
+		require.Equal(t, tc.delay, delay, "failures: %d", tc.failures)
+		require.Equal(t, tc.locked, locked, "failures: %d", tc.failures)
+	}
+
+	// no lockout without a maximum
+	delay, locked := Delay(Limit{FreeFailures: 3}, 100, time.Second, time.Minute)
+	require.Equal(t, time.Minute, delay)
+	require.False(t, locked)
+}
+
+func newTestGuard(store db.Store) *Guard {
+	return NewGuard(store, util.Config{
+		LoginBackoffBase:        time.Second,
+		LoginLockoutThreshold:   5,
+		LoginIPLockoutThreshold: 20,
+		LoginLockoutDuration:    time.Minute,
+	})
+}
+
+func TestGuardCheck(t *testing.T) {
+	username := util.RandOwner()
+	clientIP := "192.0.2.1"
+
+	testCases := []struct {
+		name     string
+		failures []db.LoginFailure
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name: "NoFailures",
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "FreeFailures",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: FreeFailures - 1, LastFailedAt: time.Now()},
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Delayed",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: FreeFailures + 1, LastFailedAt: time.Now()},
+			},
+			check: func(t *testing.T, err error) {
+				var throttled *Error
+				require.ErrorAs(t, err, &throttled)
+				require.ErrorIs(t, err, ErrTooManyAttempts)
+				require.Equal(t, 2*time.Second, throttled.RetryAfter)
+				require.False(t, throttled.Locked)
+			},
+		},
+		{
+			name: "DelayOver",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: FreeFailures + 1, LastFailedAt: time.Now().Add(-3 * time.Second)},
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "IPLockedOut",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: FreeFailures, LastFailedAt: time.Now()},
+				{Scope: ScopeIP, Key: clientIP, Failures: 20, LastFailedAt: time.Now().Add(-30 * time.Second)},
+			},
+			check: func(t *testing.T, err error) {
+				var throttled *Error
+				require.ErrorAs(t, err, &throttled)
+				require.True(t, throttled.Locked)
+				require.Equal(t, 30*time.Second, throttled.RetryAfter)
+			},
+		},
+		{
+			name: "LockoutOver",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: 5, LastFailedAt: time.Now().Add(-2 * time.Minute)},
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := newTestGuard(nil).check(tc.failures)
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestGuardAttempt(t *testing.T) {
+	username := util.RandOwner()
+	clientIP := "192.0.2.1"
+	errWrongPassword := errors.New("wrong password")
+
+	testCases := []struct {
+		name       string
+		failures   []db.LoginFailure
+		login      func() error
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, err error, attempted bool)
+	}{
+		{
+			name: "OK",
+			login: func() error {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClearLoginFailures(gomock.Any(), gomock.Eq(username)).
+					Times(1)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error, attempted bool) {
+				require.NoError(t, err)
+				require.True(t, attempted)
+			},
+		},
+		{
+			name: "Failed",
+			login: func() error {
+				return Failed(errWrongPassword)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureParams) ([]db.LoginFailure, error) {
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, clientIP, arg.ClientIp)
+						require.WithinDuration(t, time.Now().Add(-time.Minute), arg.ResetBefore, time.Second)
+						return []db.LoginFailure{{Scope: ScopeUsername, Key: username, Failures: 5}}, nil
+					})
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error, attempted bool) {
+				require.ErrorIs(t, err, ErrLoginFailed)
+				require.ErrorIs(t, err, errWrongPassword)
+				require.True(t, attempted)
+			},
+		},
+		{
+			name: "Throttled",
+			failures: []db.LoginFailure{
+				{Scope: ScopeUsername, Key: username, Failures: FreeFailures, LastFailedAt: time.Now()},
+			},
+			login: func() error {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error, attempted bool) {
+				var throttled *Error
+				require.ErrorAs(t, err, &throttled)
+				require.False(t, attempted)
+			},
+		},
+		{
+			name: "LoginError",
+			login: func() error {
+				return sql.ErrConnDone
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error, attempted bool) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrLoginFailed)
+				require.True(t, attempted)
+			},
+		},
+		{
+			name: "RecordError",
+			login: func() error {
+				return Failed(errWrongPassword)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, err error, attempted bool) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrLoginFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+
+			store.EXPECT().
+				GetLoginFailures(gomock.Any(), gomock.Eq(db.GetLoginFailuresParams{
+					Username: username,
+					ClientIp: clientIP,
+				})).
+				Times(1).
+				Return(tc.failures, nil)
+			tc.buildStubs(store)
+
+			attempted := false
+			err := newTestGuard(store).Attempt(context.Background(), username, clientIP, func() error {
+				attempted = true
+				return tc.login()
+			})
+			tc.check(t, err, attempted)
+		})
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetLoginFailures(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	err := newTestGuard(store).Attempt(context.Background(), username, clientIP, func() error {
+		t.Fatal("login must not run without checking the failures")
+		return nil
+	})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
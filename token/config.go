@@ -0,0 +1,126 @@
+package token
+
+import (
+	"crypto"
+	"fmt"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+)
+
+// Token formats that TOKEN_FORMAT selects between.
+const (
+	FormatPaseto = "paseto"
+	FormatJWT    = "jwt"
+)
+
+// NewMakerFromConfig creates the maker that servers issue tokens with. It
+// signs tokens with the active key of the keyring when TOKEN_PRIVATE_KEY_FILE
+// or TOKEN_KEY_DIR is set, and falls back to TOKEN_SYMMETRIC_KEY otherwise.
+// TOKEN_FORMAT picks PASETO, the default, or JWT.
+func NewMakerFromConfig(config util.Config) (Maker, error) {
+	format, err := tokenFormat(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TokenPrivateKeyFile == "" && config.TokenKeyDir == "" {
+		if format == FormatJWT {
+			return NewJWTMaker(config.TokenSymmetricKey)
+		}
+		return NewPasetoMaker(config.TokenSymmetricKey)
+	}
+
+	keyring, err := newKeyringFromConfig(config, config.TokenPrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// a public key handed out to other services must match the one we sign for
+	if config.TokenPrivateKeyFile != "" && config.TokenPublicKeyFile != "" {
+		publicKey, err := LoadKeyFile(config.TokenPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		active, _ := keyring.Active()
+		if !publicKey.PublicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(active.PublicKey) {
+			return nil, fmt.Errorf("public key %s does not belong to private key %s",
+				config.TokenPublicKeyFile, config.TokenPrivateKeyFile)
+		}
+	}
+
+	if format == FormatJWT {
+		return NewJWTKeyringMaker(keyring)
+	}
+	return NewKeyringMaker(keyring)
+}
+
+// NewVerifierFromConfig creates a verifier for services that only check
+// tokens, from the public key in TOKEN_PUBLIC_KEY_FILE or the keys in
+// TOKEN_KEY_DIR.
+func NewVerifierFromConfig(config util.Config) (Verifier, error) {
+	format, err := tokenFormat(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TokenPublicKeyFile == "" && config.TokenKeyDir == "" {
+		return nil, fmt.Errorf("TOKEN_PUBLIC_KEY_FILE or TOKEN_KEY_DIR must be set")
+	}
+
+	keyring, err := newKeyringFromConfig(config, config.TokenPublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatJWT {
+		return NewJWTKeyringVerifier(keyring)
+	}
+	return NewKeyringVerifier(keyring)
+}
+
+func tokenFormat(config util.Config) (string, error) {
+	switch config.TokenFormat {
+	case "", FormatPaseto:
+		return FormatPaseto, nil
+	case FormatJWT:
+		return FormatJWT, nil
+	default:
+		return "", fmt.Errorf("unsupported TOKEN_FORMAT %q", config.TokenFormat)
+	}
+}
+
+// newKeyringFromConfig loads the keys of TOKEN_KEY_DIR, with the one named
+// TOKEN_ACTIVE_KEY_ID active, or else activeFile as the active key and
+// TOKEN_RETIRED_KEY_FILES as the retired ones.
+func newKeyringFromConfig(config util.Config, activeFile string) (*Keyring, error) {
+	var cutoff time.Time
+	if config.TokenRetiredKeyCutoff != "" {
+		var err error
+		cutoff, err = time.Parse(time.RFC3339, config.TokenRetiredKeyCutoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOKEN_RETIRED_KEY_CUTOFF: %w", err)
+		}
+	}
+
+	if config.TokenKeyDir != "" {
+		keys, err := LoadKeyDir(config.TokenKeyDir)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyring(config.TokenActiveKeyID, keys, cutoff)
+	}
+
+	active, err := LoadKeyFile(activeFile)
+	if err != nil {
+		return nil, err
+	}
+	keys := []Key{active}
+	for _, path := range config.TokenRetiredKeyFiles {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(active.ID, keys, cutoff)
+}
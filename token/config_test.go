@@ -0,0 +1,83 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a new Ed25519 key pair as PEM files in dir.
+func writeKeyPair(t *testing.T, dir string, name string) (privateKeyFile string, publicKeyFile string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	privateKeyFile = filepath.Join(dir, name+".pem")
+	publicKeyFile = filepath.Join(dir, name+".pub.pem")
+	err = os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	require.NoError(t, err)
+	err = os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+	require.NoError(t, err)
+
+	return privateKeyFile, publicKeyFile
+}
+
+func TestNewMakerFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	privateKeyFile, publicKeyFile := writeKeyPair(t, dir, "token")
+
+	// without key files tokens stay symmetric
+	maker, err := NewMakerFromConfig(util.Config{TokenSymmetricKey: util.RandomString(32)})
+	require.NoError(t, err)
+	require.IsType(t, &PasetoMaker{}, maker)
+
+	maker, err = NewMakerFromConfig(util.Config{
+		TokenPrivateKeyFile: privateKeyFile,
+		TokenPublicKeyFile:  publicKeyFile,
+	})
+	require.NoError(t, err)
+	require.IsType(t, &KeyringMaker{}, maker)
+
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := NewVerifierFromConfig(util.Config{TokenPublicKeyFile: publicKeyFile})
+	require.NoError(t, err)
+	verified, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+}
+
+func TestNewMakerFromConfigInvalidKeys(t *testing.T) {
+	dir := t.TempDir()
+	privateKeyFile, _ := writeKeyPair(t, dir, "token")
+	_, otherPublicKeyFile := writeKeyPair(t, dir, "other")
+
+	_, err := NewMakerFromConfig(util.Config{
+		TokenPrivateKeyFile: privateKeyFile,
+		TokenPublicKeyFile:  otherPublicKeyFile,
+	})
+	require.ErrorContains(t, err, "does not belong to")
+
+	_, err = NewMakerFromConfig(util.Config{TokenPrivateKeyFile: filepath.Join(dir, "missing.pem")})
+	require.Error(t, err)
+
+	// a public key is not a private key
+	_, err = NewMakerFromConfig(util.Config{TokenPrivateKeyFile: otherPublicKeyFile})
+	require.ErrorContains(t, err, "private key")
+
+	_, err = NewVerifierFromConfig(util.Config{})
+	require.Error(t, err)
+}
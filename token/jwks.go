@@ -0,0 +1,88 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// KeyPublisher is implemented by makers and verifiers whose tokens can be
+// checked with public keys published as a JWK set.
+type KeyPublisher interface {
+	JWKS() (JWKS, error)
+}
+
+// JWKS is a JSON Web Key set as described in RFC 7517.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public part of a signing key, as described in RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// NewJWKS publishes the public parts of keys.
+func NewJWKS(keys []Key) (JWKS, error) {
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := NewJWK(key)
+		if err != nil {
+			return JWKS{}, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+// NewJWK describes the public part of key.
+func NewJWK(key Key) (JWK, error) {
+	method, err := jwtSigningMethod(key.PublicKey)
+	if err != nil {
+		return JWK{}, fmt.Errorf("invalid key %q: %w", key.ID, err)
+	}
+
+	jwk := JWK{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: method.Alg(),
+	}
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeJWKValue(publicKey.N.Bytes())
+		jwk.E = encodeJWKValue(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+		if err != nil {
+			return JWK{}, fmt.Errorf("invalid key %q: %w", key.ID, err)
+		}
+		// uncompressed point: 0x04 || x || y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = publicKey.Curve.Params().Name
+		jwk.X = encodeJWKValue(point[1 : 1+size])
+		jwk.Y = encodeJWKValue(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encodeJWKValue(publicKey)
+	}
+	return jwk, nil
+}
+
+func encodeJWKValue(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -0,0 +1,134 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeySize = 2048
+
+// jwtSigningMethod returns the algorithm tokens of publicKey are signed with:
+// RS256 for RSA keys, ES256 for P-256 keys and EdDSA for Ed25519 keys.
+func jwtSigningMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeySize {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeySize)
+		}
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ECDSA keys must be on the P-256 curve")
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", publicKey)
+	}
+}
+
+// JWTKeyringVerifier checks JWTs with the key named by the kid in their header.
+type JWTKeyringVerifier struct {
+	keyring *Keyring
+}
+
+// NewJWTKeyringVerifier creates a verifier for the RSA, P-256 and Ed25519
+// keys of keyring.
+func NewJWTKeyringVerifier(keyring *Keyring) (*JWTKeyringVerifier, error) {
+	for _, key := range keyring.Keys() {
+		if _, err := jwtSigningMethod(key.PublicKey); err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", key.ID, err)
+		}
+	}
+	return &JWTKeyringVerifier{keyring: keyring}, nil
+}
+
+func (verifier *JWTKeyringVerifier) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		key, err := verifier.keyring.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// each key signs with one algorithm only, whatever the header claims
+		method, err := jwtSigningMethod(key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign %s tokens", kid, token.Method.Alg())
+		}
+		return key.PublicKey, nil
+	}
+
+	return parseJWT(token, keyFunc, []string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodES256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	})
+}
+
+// JWKS returns the public keys that tokens are accepted from.
+func (verifier *JWTKeyringVerifier) JWKS() (JWKS, error) {
+	return NewJWKS(verifier.keyring.Published())
+}
+
+// JWTKeyringMaker signs JWTs with the active key of a keyring, naming it in
+// the kid header, so that other services can verify them with the public
+// keys published in a JWKS.
+type JWTKeyringMaker struct {
+	*JWTKeyringVerifier
+	active Key
+	method jwt.SigningMethod
+}
+
+func NewJWTKeyringMaker(keyring *Keyring) (Maker, error) {
+	verifier, err := NewJWTKeyringVerifier(keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	active, ok := keyring.Active()
+	if !ok {
+		return nil, fmt.Errorf("keyring has no active key")
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", active.ID)
+	}
+	method, err := jwtSigningMethod(active.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &JWTKeyringMaker{
+		JWTKeyringVerifier: verifier,
+		active:             active,
+		method:             method,
+	}
+	return maker, nil
+}
+
+func (maker *JWTKeyringMaker) CreateToken(username string, role string, duration time.Duration, options ...PayloadOption) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, options...)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, newJWTClaims(payload))
+	jwtToken.Header["kid"] = maker.active.ID
+
+	token, err := jwtToken.SignedString(maker.active.PrivateKey)
+	return token, payload, err
+}
@@ -0,0 +1,261 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAKey(t *testing.T, id string) Key {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return Key{ID: id, PrivateKey: privateKey}
+}
+
+func newECDSAKey(t *testing.T, id string) Key {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return Key{ID: id, PrivateKey: privateKey}
+}
+
+func newEd25519Key(t *testing.T, id string) Key {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return Key{ID: id, PrivateKey: privateKey}
+}
+
+func TestJWTKeyringMaker(t *testing.T) {
+	testCases := []struct {
+		alg string
+		key Key
+	}{
+		{alg: "RS256", key: newRSAKey(t, "rsa")},
+		{alg: "ES256", key: newECDSAKey(t, "ec")},
+		{alg: "EdDSA", key: newEd25519Key(t, "ed")},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.alg, func(t *testing.T) {
+			keyring, err := NewKeyring(tc.key.ID, []Key{tc.key}, time.Time{})
+			require.NoError(t, err)
+			maker, err := NewJWTKeyringMaker(keyring)
+			require.NoError(t, err)
+
+			username := util.RandOwner()
+			token, payload, err := maker.CreateToken(username, util.BankerRole, time.Minute)
+			require.NoError(t, err)
+
+			verified, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, payload.ID, verified.ID)
+			require.Equal(t, username, verified.Username)
+			require.Equal(t, util.BankerRole, verified.Role)
+			require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+
+			// a standard JWT library can check the token with the published key
+			jwks, err := maker.(KeyPublisher).JWKS()
+			require.NoError(t, err)
+			require.Len(t, jwks.Keys, 1)
+			require.Equal(t, tc.alg, jwks.Keys[0].Algorithm)
+
+			parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+				require.Equal(t, tc.key.ID, token.Header["kid"])
+				return publicKeyFromJWK(t, jwks.Keys[0]), nil
+			}, jwt.WithValidMethods([]string{tc.alg}))
+			require.NoError(t, err)
+			subject, err := parsed.Claims.GetSubject()
+			require.NoError(t, err)
+			require.Equal(t, username, subject)
+		})
+	}
+}
+
+// publicKeyFromJWK decodes a JWK the way a client of the JWKS route would.
+func publicKeyFromJWK(t *testing.T, jwk JWK) crypto.PublicKey {
+	decode := func(value string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		require.NoError(t, err)
+		return data
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+		}
+	case "EC":
+		require.Equal(t, "P-256", jwk.Curve)
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(decode(jwk.X)),
+			Y:     new(big.Int).SetBytes(decode(jwk.Y)),
+		}
+	case "OKP":
+		require.Equal(t, "Ed25519", jwk.Curve)
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected key type %s", jwk.KeyType)
+	return nil
+}
+
+func TestJWTKeyringMakerRotation(t *testing.T) {
+	oldKey := newECDSAKey(t, "old")
+	newKey := newEd25519Key(t, "new")
+
+	keyring, err := NewKeyring(oldKey.ID, []Key{oldKey}, time.Time{})
+	require.NoError(t, err)
+	oldMaker, err := NewJWTKeyringMaker(keyring)
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	retired := Key{ID: oldKey.ID, PublicKey: oldKey.PrivateKey.Public()}
+	keyring, err = NewKeyring(newKey.ID, []Key{newKey, retired}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	maker, err := NewJWTKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	jwks, err := maker.(KeyPublisher).JWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, newKey.ID, jwks.Keys[0].KeyID)
+	require.Equal(t, oldKey.ID, jwks.Keys[1].KeyID)
+
+	// past the cutoff the retired key is neither accepted nor published
+	keyring, err = NewKeyring(newKey.ID, []Key{newKey, retired}, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	maker, err = NewJWTKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	jwks, err = maker.(KeyPublisher).JWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, newKey.ID, jwks.Keys[0].KeyID)
+}
+
+func TestJWTKeyringMakerAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	keyring, err := NewKeyring(key.ID, []Key{key}, time.Time{})
+	require.NoError(t, err)
+	maker, err := NewJWTKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandOwner(), util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	// HS256 with the public key as the secret, the classic attack on RS256
+	publicDER, err := x509.MarshalPKIXPublicKey(key.PrivateKey.Public())
+	require.NoError(t, err)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newJWTClaims(payload))
+	jwtToken.Header["kid"] = key.ID
+	token, err := jwtToken.SignedString(publicDER)
+	require.NoError(t, err)
+
+	verified, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+
+	// a token without a kid
+	jwtToken = jwt.NewWithClaims(jwt.SigningMethodRS256, newJWTClaims(payload))
+	token, err = jwtToken.SignedString(key.PrivateKey)
+	require.NoError(t, err)
+
+	verified, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+}
+
+func TestExpiredJWTKeyringToken(t *testing.T) {
+	key := newEd25519Key(t, "ed")
+	keyring, err := NewKeyring(key.ID, []Key{key}, time.Time{})
+	require.NoError(t, err)
+	maker, err := NewJWTKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTKeyringMakerUnsupportedKeys(t *testing.T) {
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	keyring, err := NewKeyring("weak", []Key{{ID: "weak", PrivateKey: weakKey}}, time.Time{})
+	require.NoError(t, err)
+	_, err = NewJWTKeyringMaker(keyring)
+	require.Error(t, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	keyring, err = NewKeyring("p384", []Key{{ID: "p384", PrivateKey: p384Key}}, time.Time{})
+	require.NoError(t, err)
+	_, err = NewJWTKeyringMaker(keyring)
+	require.Error(t, err)
+
+	// PASETO v4.public only signs with Ed25519
+	keyring, err = NewKeyring("rsa", []Key{newRSAKey(t, "rsa")}, time.Time{})
+	require.NoError(t, err)
+	_, err = NewKeyringMaker(keyring)
+	require.Error(t, err)
+}
+
+func TestNewMakerFromConfigJWT(t *testing.T) {
+	maker, err := NewMakerFromConfig(util.Config{
+		TokenFormat:       FormatJWT,
+		TokenSymmetricKey: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &JWTMaker{}, maker)
+	_, ok := maker.(KeyPublisher)
+	require.False(t, ok)
+
+	dir := t.TempDir()
+	writeKeyPair(t, dir, "2026-10")
+	maker, err = NewMakerFromConfig(util.Config{
+		TokenFormat:      FormatJWT,
+		TokenKeyDir:      dir,
+		TokenActiveKeyID: "2026-10",
+	})
+	require.NoError(t, err)
+	require.IsType(t, &JWTKeyringMaker{}, maker)
+
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := NewVerifierFromConfig(util.Config{
+		TokenFormat:      FormatJWT,
+		TokenKeyDir:      dir,
+		TokenActiveKeyID: "2026-10",
+	})
+	require.NoError(t, err)
+	verified, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+
+	_, err = NewMakerFromConfig(util.Config{TokenFormat: "macaroon"})
+	require.ErrorContains(t, err, "TOKEN_FORMAT")
+}
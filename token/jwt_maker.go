@@ -3,13 +3,78 @@ package token
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 const minSecretKeysize = 32
 
+// jwtClaims carries a payload in the registered JWT claims, so that standard
+// JWT libraries know whom a token is for and when it expires.
+type jwtClaims struct {
+	Role string `json:"role"`
+	// Scope holds the scopes of the payload separated by spaces, as in OAuth.
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func newJWTClaims(payload *Payload) *jwtClaims {
+	return &jwtClaims{
+		Role:     payload.Role,
+		Scope:    strings.Join(payload.Scopes, " "),
+		ClientID: payload.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			Subject:   payload.Username,
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+	}
+}
+
+func (claims *jwtClaims) payload() (*Payload, error) {
+	id, err := uuid.Parse(claims.ID)
+	if err != nil || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+	payload := &Payload{
+		ID:        id,
+		Username:  claims.Subject,
+		Role:      claims.Role,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+		ClientID:  claims.ClientID,
+	}
+	if claims.Scope != "" {
+		payload.Scopes = strings.Fields(claims.Scope)
+	}
+	return payload, nil
+}
+
+// parseJWT verifies token with the key that keyFunc picks and returns its payload.
+func parseJWT(token string, keyFunc jwt.Keyfunc, validMethods []string) (*Payload, error) {
+	jwtToken, err := jwt.ParseWithClaims(token, &jwtClaims{}, keyFunc,
+		jwt.WithValidMethods(validMethods),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := jwtToken.Claims.(*jwtClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return claims.payload()
+}
+
 type JWTMaker struct {
 	secretKey string
 }
@@ -22,13 +87,13 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 	return &JWTMaker{secretKey}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration, options ...PayloadOption) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, options...)
 	if err != nil {
 		return "", payload, err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newJWTClaims(payload))
 
 	token, err := jwtToken.SignedString([]byte(maker.secretKey))
 	return token, payload, err
@@ -36,26 +101,8 @@ func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (str
 
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
-			return nil, ErrInvalidToken
-		}
 		return []byte(maker.secretKey), nil
 	}
 
-	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
-	if err != nil {
-		verr, ok := err.(*jwt.ValidationError)
-		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
-			return nil, ErrExpiredToken
-		}
-		return nil, ErrInvalidToken
-	}
-
-	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
-		return nil, ErrInvalidToken
-	}
-
-	return payload, nil
+	return parseJWT(token, keyFunc, []string{jwt.SigningMethodHS256.Alg()})
 }
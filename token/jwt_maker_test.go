@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/HzTTT/simple_bank/util"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,9 +15,10 @@ func TestJWTMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandOwner()
+	role := util.BankerRole
 	duration := time.Second
 
-	token, payload, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	fmt.Println(token)
@@ -27,6 +28,7 @@ func TestJWTMaker(t *testing.T) {
 	require.NotEmpty(t, payload)
 
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.NotZero(t, payload.ID)
 	require.WithinDuration(t, payload.ExpiredAt, payload.IssuedAt, time.Minute)
 }
@@ -35,7 +37,7 @@ func TestExpiredJWTToken(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(util.RandOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -46,10 +48,10 @@ func TestExpiredJWTToken(t *testing.T) {
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(util.RandOwner(), time.Minute)
+	payload, err := NewPayload(util.RandOwner(), util.DepositorRole, time.Minute)
 	require.NoError(t, err)
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, newJWTClaims(payload))
 	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
 	require.NoError(t, err)
 
@@ -61,3 +63,29 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestJWTTokenScopes(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	scopes := []string{"accounts:read", "transfers:write"}
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute,
+		WithScopes(scopes...),
+		WithClientID("partner"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, scopes, payload.Scopes)
+
+	verified, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, scopes, verified.Scopes)
+	require.Equal(t, "partner", verified.ClientID)
+
+	// tokens without scopes don't grow an empty list
+	token, _, err = maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	verified, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Nil(t, verified.Scopes)
+	require.Empty(t, verified.ClientID)
+}
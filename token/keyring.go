@@ -0,0 +1,171 @@
+package token
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("token was signed by an unknown key")
+
+// Key is a signing key known by its ID. Keys that only verify tokens have no
+// private part.
+type Key struct {
+	ID         string
+	PublicKey  crypto.PublicKey
+	PrivateKey crypto.Signer
+}
+
+// Keyring holds the key new tokens are signed with and the retired keys
+// that older tokens were signed with. Tokens of retired keys are accepted
+// until the cutoff, or until they expire if there is none.
+type Keyring struct {
+	activeID string
+	keys     map[string]Key
+	cutoff   time.Time
+}
+
+// NewKeyring creates a keyring whose active key is activeID. Services that
+// only verify tokens need no private keys, not even for the active one.
+func NewKeyring(activeID string, keys []Key, cutoff time.Time) (*Keyring, error) {
+	keyring := &Keyring{
+		activeID: activeID,
+		keys:     make(map[string]Key, len(keys)),
+		cutoff:   cutoff,
+	}
+
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("key id is required")
+		}
+		if _, ok := keyring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		if key.PublicKey == nil && key.PrivateKey != nil {
+			key.PublicKey = key.PrivateKey.Public()
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	if _, ok := keyring.keys[activeID]; activeID != "" && !ok {
+		return nil, fmt.Errorf("active key %q not found", activeID)
+	}
+
+	return keyring, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (keyring *Keyring) Active() (Key, bool) {
+	key, ok := keyring.keys[keyring.activeID]
+	return key, ok
+}
+
+// Lookup returns the key a token names, unless it was retired before the cutoff.
+func (keyring *Keyring) Lookup(id string) (Key, error) {
+	key, ok := keyring.keys[id]
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	if id != keyring.activeID && !keyring.cutoff.IsZero() && time.Now().After(keyring.cutoff) {
+		return Key{}, fmt.Errorf("key %q was retired at %s", id, keyring.cutoff)
+	}
+	return key, nil
+}
+
+// Published returns the keys that tokens are still accepted from, the active
+// one first.
+func (keyring *Keyring) Published() []Key {
+	var keys []Key
+	if active, ok := keyring.Active(); ok {
+		keys = append(keys, active)
+	}
+
+	ids := make([]string, 0, len(keyring.keys))
+	for id := range keyring.keys {
+		if id != keyring.activeID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if key, err := keyring.Lookup(id); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Keys returns every key of the keyring.
+func (keyring *Keyring) Keys() []Key {
+	keys := make([]Key, 0, len(keyring.keys))
+	for _, key := range keyring.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// LoadKeyFile reads a PKCS #8 private key or a PKIX public key from a PEM
+// file. The key ID is the file name without its ".pem" and ".pub" extensions,
+// so a key pair is best named like 2026-10.pem and 2026-10.pub.pem.
+func LoadKeyFile(path string) (Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{ID: keyID(path)}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key.PrivateKey, err = parsePrivateKey(path, block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		key.PublicKey = key.PrivateKey.Public()
+	case "PUBLIC KEY":
+		key.PublicKey, err = parsePublicKey(path, block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+	default:
+		return Key{}, fmt.Errorf("%s contains an unsupported %s PEM block", path, block.Type)
+	}
+	return key, nil
+}
+
+// LoadKeyDir reads every ".pem" file in dir as a key, see LoadKeyFile. When
+// both halves of a key pair are there, the private key is used.
+func LoadKeyDir(dir string) ([]Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no keys found in %s", dir)
+	}
+
+	keys := make([]Key, 0, len(paths))
+	index := make(map[string]int, len(paths))
+	for _, path := range paths {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[key.ID]
+		if !ok {
+			index[key.ID] = len(keys)
+			keys = append(keys, key)
+		} else if key.PrivateKey != nil {
+			keys[i] = key
+		}
+	}
+	return keys, nil
+}
+
+func keyID(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".pem")
+	return strings.TrimSuffix(name, ".pub")
+}
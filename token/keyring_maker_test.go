@@ -0,0 +1,220 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomKey(t *testing.T, id string) Key {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return Key{ID: id, PrivateKey: privateKey}
+}
+
+func TestKeyringMakerRotation(t *testing.T) {
+	oldKey := randomKey(t, "old")
+	newKey := randomKey(t, "new")
+
+	keyring, err := NewKeyring(oldKey.ID, []Key{oldKey}, time.Time{})
+	require.NoError(t, err)
+	oldMaker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	oldToken, oldPayload, err := oldMaker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// rotate, keeping only the public part of the retired key
+	retired := Key{ID: oldKey.ID, PublicKey: oldKey.PrivateKey.Public()}
+	keyring, err = NewKeyring(newKey.ID, []Key{newKey, retired}, time.Time{})
+	require.NoError(t, err)
+	maker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, oldPayload.ID, payload.ID)
+
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.BankerRole, time.Minute)
+	require.NoError(t, err)
+
+	footer, err := paseto.NewParser().UnsafeParseFooter(paseto.V4Public, token)
+	require.NoError(t, err)
+	var kf keyFooter
+	require.NoError(t, json.Unmarshal(footer, &kf))
+	require.Equal(t, newKey.ID, kf.KeyID)
+
+	verified, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, util.BankerRole, verified.Role)
+
+	// the old maker doesn't know the new key
+	verified, err = oldMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+}
+
+func TestKeyringMakerCutoff(t *testing.T) {
+	oldKey := randomKey(t, "old")
+	newKey := randomKey(t, "new")
+
+	keyring, err := NewKeyring(oldKey.ID, []Key{oldKey}, time.Time{})
+	require.NoError(t, err)
+	oldMaker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandOwner(), util.DepositorRole, time.Hour)
+	require.NoError(t, err)
+
+	keyring, err = NewKeyring(newKey.ID, []Key{newKey, oldKey}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	maker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	keyring, err = NewKeyring(newKey.ID, []Key{newKey, oldKey}, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	maker, err = NewKeyringMaker(keyring)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	// the active key is never cut off
+	token, _, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+}
+
+func TestKeyringMakerForgedKeyID(t *testing.T) {
+	key := randomKey(t, "a")
+	otherKey := randomKey(t, "b")
+	keyring, err := NewKeyring(key.ID, []Key{key, otherKey}, time.Time{})
+	require.NoError(t, err)
+	maker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	// a token signed by b but claiming to be signed by a
+	secretKey, err := paseto.NewV4AsymmetricSecretKeyFromEd25519(otherKey.PrivateKey.(ed25519.PrivateKey))
+	require.NoError(t, err)
+	claims, err := json.Marshal(&Payload{Username: util.RandOwner(), ExpiredAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	forged, err := paseto.NewTokenFromClaimsJSON(claims, []byte(`{"kid":"a"}`))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(forged.V4Sign(secretKey, nil))
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	forged.SetFooter([]byte(`{"kid":"unknown"}`))
+	payload, err = maker.VerifyToken(forged.V4Sign(secretKey, nil))
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestKeyringMakerLocalToken(t *testing.T) {
+	key := randomKey(t, "a")
+	keyring, err := NewKeyring(key.ID, []Key{key}, time.Time{})
+	require.NoError(t, err)
+	maker, err := NewKeyringMaker(keyring)
+	require.NoError(t, err)
+
+	// a v2.local token is not accepted in place of a v4.public one
+	localMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	localToken, _, err := localMaker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	verified, err := maker.VerifyToken(localToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+}
+
+func TestNewKeyring(t *testing.T) {
+	key := randomKey(t, "a")
+
+	_, err := NewKeyring("missing", []Key{key}, time.Time{})
+	require.Error(t, err)
+
+	_, err = NewKeyring(key.ID, []Key{key, randomKey(t, "a")}, time.Time{})
+	require.ErrorContains(t, err, "duplicate")
+
+	// a keyring without a private active key can verify but not sign
+	keyring, err := NewKeyring(key.ID, []Key{{ID: key.ID, PublicKey: key.PrivateKey.Public()}}, time.Time{})
+	require.NoError(t, err)
+	_, err = NewKeyringVerifier(keyring)
+	require.NoError(t, err)
+	_, err = NewKeyringMaker(keyring)
+	require.Error(t, err)
+}
+
+func TestLoadKeyDir(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyPair(t, dir, "2026-09")
+	writeKeyPair(t, dir, "2026-10")
+
+	keys, err := LoadKeyDir(dir)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	for _, key := range keys {
+		// the private half of each pair wins over the public one
+		require.NotNil(t, key.PrivateKey)
+	}
+
+	maker, err := NewMakerFromConfig(util.Config{
+		TokenKeyDir:      dir,
+		TokenActiveKeyID: "2026-10",
+	})
+	require.NoError(t, err)
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = NewMakerFromConfig(util.Config{TokenKeyDir: dir, TokenActiveKeyID: "2026-11"})
+	require.Error(t, err)
+
+	verifier, err := NewVerifierFromConfig(util.Config{TokenKeyDir: dir, TokenActiveKeyID: "2026-10"})
+	require.NoError(t, err)
+	verified, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+
+	_, err = LoadKeyDir(t.TempDir())
+	require.Error(t, err)
+}
+
+func TestNewMakerFromConfigRetiredKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldPrivateKeyFile, oldPublicKeyFile := writeKeyPair(t, dir, "old")
+	newPrivateKeyFile, _ := writeKeyPair(t, dir, "new")
+
+	oldMaker, err := NewMakerFromConfig(util.Config{TokenPrivateKeyFile: oldPrivateKeyFile})
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	config := util.Config{
+		TokenPrivateKeyFile:  newPrivateKeyFile,
+		TokenRetiredKeyFiles: []string{oldPublicKeyFile},
+	}
+	maker, err := NewMakerFromConfig(config)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	config.TokenRetiredKeyCutoff = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	maker, err = NewMakerFromConfig(config)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	config.TokenRetiredKeyCutoff = "tomorrow"
+	_, err = NewMakerFromConfig(config)
+	require.ErrorContains(t, err, "TOKEN_RETIRED_KEY_CUTOFF")
+}
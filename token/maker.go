@@ -3,7 +3,15 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string,*Payload,error)
+	// CreateToken issues a token for username. Options fill in the rest of
+	// the payload, such as the scopes it is limited to.
+	CreateToken(username string, role string, duration time.Duration, options ...PayloadOption) (string,*Payload,error)
 
+	Verifier
+}
+
+// Verifier checks tokens without being able to create them, which is all a
+// service holding only a public key can do.
+type Verifier interface {
 	VerifyToken(token string) (*Payload,error)
-}
\ No newline at end of file
+}
@@ -24,8 +24,8 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string,*Payload ,error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration, options ...PayloadOption) (string,*Payload ,error) {
+	payload, err := NewPayload(username, role, duration, options...)
 	if err != nil {
 		return "",payload,err
 	}
@@ -14,8 +14,9 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, maker)
 
 	username := util.RandOwner()
+	role := util.BankerRole
 
-	token, payload, err := maker.CreateToken(username, time.Minute)
+	token, payload, err := maker.CreateToken(username, role, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -24,6 +25,7 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, payload)
 
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.NotZero(t, payload.ID)
 
 }
@@ -32,7 +34,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(util.RandOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -63,3 +65,20 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.NotEmpty(t, payload)
 }
 */
+
+func TestPasetoTokenScopes(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	scopes := []string{"accounts:read"}
+	token, _, err := maker.CreateToken(util.RandOwner(), util.DepositorRole, time.Minute,
+		WithScopes(scopes...),
+		WithClientID("partner"),
+	)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, scopes, payload.Scopes)
+	require.Equal(t, "partner", payload.ClientID)
+}
@@ -10,16 +10,43 @@ import (
 var (
 	ErrExpiredToken = errors.New("token has expired")
 	ErrInvalidToken = errors.New("token is invalid")
+	// ErrPasswordChanged is reported for tokens issued before the user last
+	// changed their password, which servers reject even though the token
+	// itself is still valid.
+	ErrPasswordChanged = errors.New("token was issued before the password was changed")
 )
 
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// Scopes limits what the token grants. Tokens without scopes grant
+	// everything their user may do.
+	Scopes []string `json:"scopes,omitempty"`
+	// ClientID names the OAuth client the token was issued to, if any.
+	ClientID string `json:"client_id,omitempty"`
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// PayloadOption sets optional fields of a new payload.
+type PayloadOption func(payload *Payload)
+
+// WithScopes limits a payload to scopes.
+func WithScopes(scopes ...string) PayloadOption {
+	return func(payload *Payload) {
+		payload.Scopes = scopes
+	}
+}
+
+// WithClientID marks a payload as issued to an OAuth client.
+func WithClientID(clientID string) PayloadOption {
+	return func(payload *Payload) {
+		payload.ClientID = clientID
+	}
+}
+
+func NewPayload(username string, role string, duration time.Duration, options ...PayloadOption) (*Payload, error) {
 	tokenID, err := uuid.NewUUID()
 	if err != nil {
 		return nil, err
@@ -28,9 +55,13 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
+	for _, option := range options {
+		option(payload)
+	}
 
 	return payload, nil
 }
@@ -41,3 +72,11 @@ func (payload *Payload) Valid() error {
 	}
 	return nil
 }
+
+// IssuedBefore reports whether the token was issued in an earlier second
+// than t. JWTs only carry their issue time in whole seconds, so both times
+// are compared to the second; otherwise a token issued right after a
+// password change could look older than the change.
+func (payload *Payload) IssuedBefore(t time.Time) bool {
+	return payload.IssuedAt.Truncate(time.Second).Before(t.Truncate(time.Second))
+}
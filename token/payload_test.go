@@ -0,0 +1,53 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	changedAt := time.Date(2024, 5, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	testCases := []struct {
+		name     string
+		issuedAt time.Time
+		before   bool
+	}{
+		{
+			name:     "EarlierSecond",
+			issuedAt: changedAt.Add(-time.Second),
+			before:   true,
+		},
+		{
+			name:     "SameSecondBefore",
+			issuedAt: changedAt.Add(-100 * time.Millisecond),
+			before:   false,
+		},
+		{
+			name:     "SameSecondAfter",
+			issuedAt: changedAt.Add(100 * time.Millisecond),
+			before:   false,
+		},
+		{
+			// a JWT issued right after the change only carries whole seconds
+			name:     "SameSecondTruncated",
+			issuedAt: changedAt.Truncate(time.Second),
+			before:   false,
+		},
+		{
+			name:     "LaterSecond",
+			issuedAt: changedAt.Add(time.Second),
+			before:   false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			payload := &Payload{IssuedAt: tc.issuedAt}
+			require.Equal(t, tc.before, payload.IssuedBefore(changedAt))
+		})
+	}
+}
@@ -10,10 +10,36 @@ type Config struct {
 	DBDriver             string        `mapstructure:"DB_DRIVER"`
 	DBSource             string        `mapstructure:"DB_SOURCE"`
 	HTTPServerAddress        string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	TokenFormat          string        `mapstructure:"TOKEN_FORMAT"`
 	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPrivateKeyFile  string        `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	TokenPublicKeyFile   string        `mapstructure:"TOKEN_PUBLIC_KEY_FILE"`
+	TokenRetiredKeyFiles []string      `mapstructure:"TOKEN_RETIRED_KEY_FILES"`
+	TokenKeyDir          string        `mapstructure:"TOKEN_KEY_DIR"`
+	TokenActiveKeyID     string        `mapstructure:"TOKEN_ACTIVE_KEY_ID"`
+	TokenRetiredKeyCutoff string       `mapstructure:"TOKEN_RETIRED_KEY_CUTOFF"`
+	PageTokenKey         string        `mapstructure:"PAGE_TOKEN_KEY"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
 	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TrustedProxies                []string      `mapstructure:"TRUSTED_PROXIES"`
+	ScheduledTransferPollInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_POLL_INTERVAL"`
+	WorkerConcurrency             int           `mapstructure:"WORKER_CONCURRENCY"`
+	WorkerPollInterval            time.Duration `mapstructure:"WORKER_POLL_INTERVAL"`
+	EmailSenderName               string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress            string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	SMTPAddress                   string        `mapstructure:"SMTP_ADDRESS"`
+	SMTPUsername                  string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword                  string        `mapstructure:"SMTP_PASSWORD"`
+	MailOutboxDir                 string        `mapstructure:"MAIL_OUTBOX_DIR"`
+	VerifyEmailURL                string        `mapstructure:"VERIFY_EMAIL_URL"`
+	ResetPasswordURL              string        `mapstructure:"RESET_PASSWORD_URL"`
+	TOTPIssuer                    string        `mapstructure:"TOTP_ISSUER"`
+	LoginChallengeDuration        time.Duration `mapstructure:"LOGIN_CHALLENGE_DURATION"`
+	LoginBackoffBase              time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+	LoginLockoutThreshold         int           `mapstructure:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginIPLockoutThreshold       int           `mapstructure:"LOGIN_IP_LOCKOUT_THRESHOLD"`
+	LoginLockoutDuration          time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
 
 }
 
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	secret, err := RandomSecret(32)
+	require.NoError(t, err)
+	require.Len(t, secret, 43)
+
+	secret2, err := RandomSecret(32)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, secret2)
+
+	hashedSecret := HashSecret(secret)
+	require.NotEqual(t, secret, hashedSecret)
+	require.True(t, CheckSecret(secret, hashedSecret))
+	require.False(t, CheckSecret(secret2, hashedSecret))
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+)
+
+const defaultMaxAttempts = 5
+
+// ErrPermanent marks a failure that retrying cannot fix. A handler error
+// wrapping it moves the job to dead without using up its attempts.
+var ErrPermanent = errors.New("permanent job failure")
+
+// Task names a kind of job together with the type of its payload, so that
+// producers and the handler cannot disagree on the payload shape.
+type Task[T any] struct {
+	Kind        string
+	MaxAttempts int32
+}
+
+// Enqueue queues a job that runs as soon as a worker is free. Passing the
+// Querier of a transaction makes the job commit or roll back with it.
+func (task Task[T]) Enqueue(ctx context.Context, q db.Querier, payload T) (db.Job, error) {
+	return task.EnqueueAt(ctx, q, payload, time.Now())
+}
+
+// EnqueueAt queues a job that runs no earlier than runAt.
+func (task Task[T]) EnqueueAt(ctx context.Context, q db.Querier, payload T, runAt time.Time) (db.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("cannot encode %s payload: %w", task.Kind, err)
+	}
+
+	maxAttempts := task.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	return q.CreateJob(ctx, db.CreateJobParams{
+		Kind:        task.Kind,
+		Payload:     data,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	})
+}
+
+// Handle registers handler for the jobs of task.
+func Handle[T any](runner *Runner, task Task[T], handler func(ctx context.Context, payload T) error) {
+	runner.handlers[task.Kind] = func(ctx context.Context, data json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("%w: cannot decode payload: %s", ErrPermanent, err)
+		}
+		return handler(ctx, payload)
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/mail"
+)
+
+type SendEmailChangedEmailPayload struct {
+	Username string `json:"username"`
+	OldEmail string `json:"old_email"`
+}
+
+// TaskSendEmailChangedEmail tells the previous email address of a user that
+// it no longer receives mail for the account, so that an unexpected change
+// gets noticed.
+var TaskSendEmailChangedEmail = Task[SendEmailChangedEmailPayload]{Kind: "send_email_changed_email"}
+
+// NewSendEmailChangedEmailHandler sends the notice to the old address.
+func NewSendEmailChangedEmailHandler(store db.Store, mailer mail.Mailer) func(ctx context.Context, payload SendEmailChangedEmailPayload) error {
+	return func(ctx context.Context, payload SendEmailChangedEmailPayload) error {
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: user %s not found", ErrPermanent, payload.Username)
+			}
+			return err
+		}
+
+		content := fmt.Sprintf(`Hello %s,<br/>
+The email address of your Simple Bank account was changed, so we will no longer write to this one.<br/>
+If you did not make this change, please contact us right away.<br/>`,
+			html.EscapeString(user.FullName))
+
+		return mailer.SendEmail(ctx, mail.Email{
+			To:      []string{payload.OldEmail},
+			Subject: "Your Simple Bank email address was changed",
+			Content: content,
+		})
+	}
+}
+
+// EnqueueEmailChange queues the jobs that follow a change of the email of a
+// user: verifying the new address and notifying the old one.
+func EnqueueEmailChange(ctx context.Context, q db.Querier, username string, oldEmail string) error {
+	_, err := TaskSendVerifyEmail.Enqueue(ctx, q, SendVerifyEmailPayload{
+		Username: username,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = TaskSendEmailChangedEmail.Enqueue(ctx, q, SendEmailChangedEmailPayload{
+		Username: username,
+		OldEmail: oldEmail,
+	})
+	return err
+}
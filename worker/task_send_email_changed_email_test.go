@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendEmailChangedEmail(t *testing.T) {
+	user := db.User{
+		Username: util.RandOwner(),
+		FullName: util.RandOwner(),
+		Email:    util.RandomEmail(),
+	}
+	oldEmail := util.RandomEmail()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+	mailer := &recordingMailer{}
+	handler := NewSendEmailChangedEmailHandler(store, mailer)
+	err := handler(context.Background(), SendEmailChangedEmailPayload{Username: user.Username, OldEmail: oldEmail})
+	require.NoError(t, err)
+
+	require.Len(t, mailer.emails, 1)
+	require.Equal(t, []string{oldEmail}, mailer.emails[0].To)
+	require.NotContains(t, mailer.emails[0].Content, user.Email)
+}
+
+func TestSendEmailChangedEmailUserNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+	mailer := &recordingMailer{}
+	handler := NewSendEmailChangedEmailHandler(store, mailer)
+	err := handler(context.Background(), SendEmailChangedEmailPayload{Username: util.RandOwner(), OldEmail: util.RandomEmail()})
+	require.ErrorIs(t, err, ErrPermanent)
+	require.Empty(t, mailer.emails)
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"net/url"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/mail"
+	"github.com/HzTTT/simple_bank/util"
+)
+
+const passwordResetDuration = 30 * time.Minute
+
+type SendPasswordResetEmailPayload struct {
+	Username string `json:"username"`
+}
+
+// TaskSendPasswordResetEmail mails a password reset link. The token is made
+// by the handler rather than the producer so that it is never stored in the
+// jobs table.
+var TaskSendPasswordResetEmail = Task[SendPasswordResetEmailPayload]{Kind: "send_password_reset_email"}
+
+// NewSendPasswordResetEmailHandler sends password reset emails linking to
+// resetPasswordURL with a short-lived, single-use token. Unverified
+// addresses get none.
+func NewSendPasswordResetEmailHandler(store db.Store, mailer mail.Mailer, resetPasswordURL string) func(ctx context.Context, payload SendPasswordResetEmailPayload) error {
+	return func(ctx context.Context, payload SendPasswordResetEmailPayload) error {
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: user %s not found", ErrPermanent, payload.Username)
+			}
+			return err
+		}
+		// the email may have changed since the job was queued
+		if !user.IsEmailVerified {
+			return fmt.Errorf("%w: email of user %s is not verified", ErrPermanent, user.Username)
+		}
+
+		link, err := url.Parse(resetPasswordURL)
+		if err != nil {
+			return fmt.Errorf("%w: invalid reset password url: %s", ErrPermanent, err)
+		}
+
+		token, err := util.RandomSecret(32)
+		if err != nil {
+			return err
+		}
+
+		_, err = store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: util.HashSecret(token),
+			ExpiredAt: time.Now().Add(passwordResetDuration),
+		})
+		if err != nil {
+			return err
+		}
+
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		content := fmt.Sprintf(`Hello %s,<br/>
+We received a request to reset the password of your Simple Bank account.<br/>
+Please <a href="%s">click here</a> within %d minutes to choose a new password.<br/>
+If you did not ask for this, you can ignore this email.<br/>`,
+			html.EscapeString(user.FullName), html.EscapeString(link.String()), int(passwordResetDuration.Minutes()))
+
+		return mailer.SendEmail(ctx, mail.Email{
+			To:      []string{user.Email},
+			Subject: "Reset your Simple Bank password",
+			Content: content,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"html"
+	"net/url"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendPasswordResetEmail(t *testing.T) {
+	user := db.User{
+		Username:        util.RandOwner(),
+		FullName:        util.RandOwner(),
+		Email:           util.RandomEmail(),
+		IsEmailVerified: true,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	var tokenHash string
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+			require.Equal(t, user.Username, arg.Username)
+			tokenHash = arg.TokenHash
+			return db.PasswordResetToken{}, nil
+		})
+
+	mailer := &recordingMailer{}
+	handler := NewSendPasswordResetEmailHandler(store, mailer, "http://localhost:3000/reset_password")
+	err := handler(context.Background(), SendPasswordResetEmailPayload{Username: user.Username})
+	require.NoError(t, err)
+
+	require.Len(t, mailer.emails, 1)
+	require.Equal(t, []string{user.Email}, mailer.emails[0].To)
+
+	match := linkPattern.FindStringSubmatch(mailer.emails[0].Content)
+	require.Len(t, match, 2)
+	link, err := url.Parse(html.UnescapeString(match[1]))
+	require.NoError(t, err)
+	require.Equal(t, "/reset_password", link.Path)
+	require.True(t, util.CheckSecret(link.Query().Get("token"), tokenHash))
+}
+
+func TestSendPasswordResetEmailUnverified(t *testing.T) {
+	user := db.User{
+		Username: util.RandOwner(),
+		FullName: util.RandOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+
+	mailer := &recordingMailer{}
+	handler := NewSendPasswordResetEmailHandler(store, mailer, "http://localhost:3000/reset_password")
+	err := handler(context.Background(), SendPasswordResetEmailPayload{Username: user.Username})
+	require.ErrorIs(t, err, ErrPermanent)
+	require.Empty(t, mailer.emails)
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/mail"
+	"github.com/HzTTT/simple_bank/util"
+)
+
+const verifyEmailDuration = 24 * time.Hour
+
+type SendVerifyEmailPayload struct {
+	Username string `json:"username"`
+}
+
+// TaskSendVerifyEmail asks a user to verify an email address they changed to.
+var TaskSendVerifyEmail = Task[SendVerifyEmailPayload]{Kind: "send_verify_email"}
+
+// NewSendVerifyEmailHandler sends a single-use verification link pointing at
+// verifyEmailURL to the current email address of a user.
+func NewSendVerifyEmailHandler(store db.Store, mailer mail.Mailer, verifyEmailURL string) func(ctx context.Context, payload SendVerifyEmailPayload) error {
+	return func(ctx context.Context, payload SendVerifyEmailPayload) error {
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: user %s not found", ErrPermanent, payload.Username)
+			}
+			return err
+		}
+
+		// the address was verified in the meantime, or changed and
+		// verified again by a later job
+		if user.IsEmailVerified {
+			return nil
+		}
+
+		link, err := createVerifyEmailLink(ctx, store, user, verifyEmailURL)
+		if err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf(`Hello %s,<br/>
+The email address of your Simple Bank account was changed to this one.<br/>
+Please <a href="%s">click here</a> to verify it.<br/>`,
+			html.EscapeString(user.FullName), html.EscapeString(link))
+
+		return mailer.SendEmail(ctx, mail.Email{
+			To:      []string{user.Email},
+			Subject: "Verify your new email address",
+			Content: content,
+		})
+	}
+}
+
+// createVerifyEmailLink stores a new verification code for the current email
+// of user and returns the link that redeems it.
+func createVerifyEmailLink(ctx context.Context, store db.Store, user db.User, verifyEmailURL string) (string, error) {
+	secretCode, err := util.RandomSecret(32)
+	if err != nil {
+		return "", err
+	}
+
+	verifyEmail, err := store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: util.HashSecret(secretCode),
+		ExpiredAt:      time.Now().Add(verifyEmailDuration),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	link, err := url.Parse(verifyEmailURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid verify email url: %s", ErrPermanent, err)
+	}
+	query := link.Query()
+	query.Set("email_id", strconv.FormatInt(verifyEmail.ID, 10))
+	query.Set("secret_code", secretCode)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
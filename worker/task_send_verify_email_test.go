@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendVerifyEmail(t *testing.T) {
+	user := db.User{
+		Username: util.RandOwner(),
+		FullName: util.RandOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		CreateVerifyEmail(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+			require.Equal(t, user.Email, arg.Email)
+			return db.VerifyEmail{ID: 1}, nil
+		})
+
+	mailer := &recordingMailer{}
+	handler := NewSendVerifyEmailHandler(store, mailer, "http://localhost:8080/v1/verify_email")
+	err := handler(context.Background(), SendVerifyEmailPayload{Username: user.Username})
+	require.NoError(t, err)
+	require.Len(t, mailer.emails, 1)
+	require.Equal(t, []string{user.Email}, mailer.emails[0].To)
+}
+
+func TestSendVerifyEmailAlreadyVerified(t *testing.T) {
+	user := db.User{
+		Username:        util.RandOwner(),
+		Email:           util.RandomEmail(),
+		IsEmailVerified: true,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+
+	mailer := &recordingMailer{}
+	handler := NewSendVerifyEmailHandler(store, mailer, "http://localhost:8080/v1/verify_email")
+	err := handler(context.Background(), SendVerifyEmailPayload{Username: user.Username})
+	require.NoError(t, err)
+	require.Empty(t, mailer.emails)
+}
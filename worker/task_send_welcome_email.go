@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/mail"
+)
+
+type SendWelcomeEmailPayload struct {
+	Username string `json:"username"`
+}
+
+// TaskSendWelcomeEmail greets a user after they sign up and asks them to
+// verify their email address.
+var TaskSendWelcomeEmail = Task[SendWelcomeEmailPayload]{Kind: "send_welcome_email"}
+
+// NewSendWelcomeEmailHandler sends welcome emails with a single-use
+// verification link pointing at verifyEmailURL.
+func NewSendWelcomeEmailHandler(store db.Store, mailer mail.Mailer, verifyEmailURL string) func(ctx context.Context, payload SendWelcomeEmailPayload) error {
+	return func(ctx context.Context, payload SendWelcomeEmailPayload) error {
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: user %s not found", ErrPermanent, payload.Username)
+			}
+			return err
+		}
+
+		link, err := createVerifyEmailLink(ctx, store, user, verifyEmailURL)
+		if err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf(`Hello %s,<br/>
+Thank you for registering with Simple Bank!<br/>
+Please <a href="%s">click here</a> to verify your email address.<br/>`,
+			html.EscapeString(user.FullName), html.EscapeString(link))
+
+		return mailer.SendEmail(ctx, mail.Email{
+			To:      []string{user.Email},
+			Subject: "Welcome to Simple Bank",
+			Content: content,
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/mail"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMailer struct {
+	emails []mail.Email
+}
+
+func (mailer *recordingMailer) SendEmail(ctx context.Context, email mail.Email) error {
+	mailer.emails = append(mailer.emails, email)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`href="([^"]+)"`)
+
+func TestSendWelcomeEmail(t *testing.T) {
+	user := db.User{
+		Username: util.RandOwner(),
+		FullName: util.RandOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	var verifyEmail db.VerifyEmail
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		CreateVerifyEmail(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.Equal(t, user.Email, arg.Email)
+			verifyEmail = db.VerifyEmail{
+				ID:             util.RandomInt(1, 1000),
+				Username:       arg.Username,
+				Email:          arg.Email,
+				SecretCodeHash: arg.SecretCodeHash,
+				ExpiredAt:      arg.ExpiredAt,
+			}
+			return verifyEmail, nil
+		})
+
+	mailer := &recordingMailer{}
+	handler := NewSendWelcomeEmailHandler(store, mailer, "http://localhost:8080/v1/verify_email")
+	err := handler(context.Background(), SendWelcomeEmailPayload{Username: user.Username})
+	require.NoError(t, err)
+
+	require.Len(t, mailer.emails, 1)
+	require.Equal(t, []string{user.Email}, mailer.emails[0].To)
+
+	match := linkPattern.FindStringSubmatch(mailer.emails[0].Content)
+	require.Len(t, match, 2)
+	link, err := url.Parse(html.UnescapeString(match[1]))
+	require.NoError(t, err)
+	require.Equal(t, "/v1/verify_email", link.Path)
+	require.Equal(t, strconv.FormatInt(verifyEmail.ID, 10), link.Query().Get("email_id"))
+	require.True(t, util.CheckSecret(link.Query().Get("secret_code"), verifyEmail.SecretCodeHash))
+}
+
+func TestSendWelcomeEmailUserNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+	store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+
+	mailer := &recordingMailer{}
+	handler := NewSendWelcomeEmailHandler(store, mailer, "http://localhost:8080/v1/verify_email")
+	err := handler(context.Background(), SendWelcomeEmailPayload{Username: util.RandOwner()})
+	require.ErrorIs(t, err, ErrPermanent)
+	require.Empty(t, mailer.emails)
+}
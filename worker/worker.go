@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+)
+
+const (
+	defaultConcurrency  = 4
+	defaultPollInterval = 5 * time.Second
+	// leaseDuration bounds how long a handler may run. A job still running
+	// after its lease is assumed lost and is claimed by another worker.
+	leaseDuration = 5 * time.Minute
+
+	minBackoff = 10 * time.Second
+	maxBackoff = time.Hour
+)
+
+// ErrLeaseLost is returned when a job could not be settled because its lease
+// ran out and another worker claimed it in the meantime. The other worker
+// now owns the outcome, so the result of this attempt is dropped.
+var ErrLeaseLost = errors.New("job lease lost")
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Runner claims jobs from the jobs table and runs them on a pool of workers.
+type Runner struct {
+	store        db.Store
+	handlers     map[string]handlerFunc
+	concurrency  int
+	pollInterval time.Duration
+}
+
+func New(store db.Store, concurrency int, pollInterval time.Duration) *Runner {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	return &Runner{
+		store:        store,
+		handlers:     make(map[string]handlerFunc),
+		concurrency:  concurrency,
+		pollInterval: pollInterval,
+	}
+}
+
+// Start runs the worker pool until ctx is cancelled. Each worker keeps
+// taking jobs while there are any and polls every poll interval otherwise.
+func (runner *Runner) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < runner.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (runner *Runner) work(ctx context.Context) {
+	for {
+		ran, err := runner.RunNext(ctx, time.Now())
+		if err != nil {
+			log.Printf("cannot run job: %s", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(runner.pollInterval):
+		}
+	}
+}
+
+// RunNext claims one job due at now and runs it. It reports whether there
+// was a job to run.
+func (runner *Runner) RunNext(ctx context.Context, now time.Time) (bool, error) {
+	jobs, err := runner.store.ClaimJobs(ctx, db.ClaimJobsParams{
+		LockedUntil: now.Add(leaseDuration),
+		Now:         now,
+		BatchSize:   1,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(jobs) == 0 {
+		return false, nil
+	}
+
+	return true, runner.run(ctx, jobs[0], now)
+}
+
+func (runner *Runner) run(ctx context.Context, job db.Job, now time.Time) error {
+	handler, ok := runner.handlers[job.Kind]
+	if !ok {
+		return runner.kill(ctx, job, fmt.Errorf("no handler registered for job kind %q", job.Kind))
+	}
+
+	// a worker died while running the last attempt
+	if job.Attempts > job.MaxAttempts {
+		return runner.kill(ctx, job, errors.New("lease expired on the last attempt"))
+	}
+
+	err := runner.handle(ctx, handler, job)
+	if err == nil {
+		_, err = runner.store.CompleteJob(ctx, db.CompleteJobParams{
+			ID:          job.ID,
+			Attempts:    job.Attempts,
+			LockedUntil: job.LockedUntil,
+		})
+		return settled(job, err)
+	}
+
+	if errors.Is(err, ErrPermanent) || job.Attempts >= job.MaxAttempts {
+		return runner.kill(ctx, job, err)
+	}
+
+	log.Printf("job [%d] %s failed on attempt %d, retrying: %s", job.ID, job.Kind, job.Attempts, err)
+	_, err = runner.store.RetryJob(ctx, db.RetryJobParams{
+		ID:          job.ID,
+		Attempts:    job.Attempts,
+		LockedUntil: job.LockedUntil,
+		RunAt:       now.Add(Backoff(job.Attempts)),
+		LastError:   err.Error(),
+	})
+	return settled(job, err)
+}
+
+// handle runs handler within the job lease, turning a panic into an error
+// so that one bad job cannot take a worker down.
+func (runner *Runner) handle(ctx context.Context, handler handlerFunc, job db.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, leaseDuration)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
+
+func (runner *Runner) kill(ctx context.Context, job db.Job, cause error) error {
+	log.Printf("job [%d] %s is dead after %d attempts: %s", job.ID, job.Kind, job.Attempts, cause)
+	_, err := runner.store.KillJob(ctx, db.KillJobParams{
+		ID:          job.ID,
+		Attempts:    job.Attempts,
+		LockedUntil: job.LockedUntil,
+		LastError:   cause.Error(),
+	})
+	return settled(job, err)
+}
+
+// settled turns the error of settling job into ErrLeaseLost when no row
+// matched the lease it was claimed with.
+func settled(job db.Job, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("job [%d] attempt %d: %w", job.ID, job.Attempts, ErrLeaseLost)
+	}
+	return err
+}
+
+// Backoff returns how long to wait before retrying a job that failed its
+// attempt-th run. The delay doubles with every attempt up to an hour.
+func Backoff(attempt int32) time.Duration {
+	delay := minBackoff
+	for i := int32(1); i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
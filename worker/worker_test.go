@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/HzTTT/simple_bank/db/mock"
+	db "github.com/HzTTT/simple_bank/db/sqlc"
+	"github.com/HzTTT/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testPayload struct {
+	Value string `json:"value"`
+}
+
+var testTask = Task[testPayload]{Kind: "test", MaxAttempts: 3}
+
+func randomJob(t *testing.T, attempts int32) db.Job {
+	payload, err := json.Marshal(testPayload{Value: util.RandomString(6)})
+	require.NoError(t, err)
+
+	return db.Job{
+		ID:          util.RandomInt(1, 1000),
+		Kind:        testTask.Kind,
+		Payload:     payload,
+		Status:      db.JobStatusRunning,
+		Attempts:    attempts,
+		MaxAttempts: testTask.MaxAttempts,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(leaseDuration), Valid: true},
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	runAt := time.Now().Add(time.Minute)
+	store.EXPECT().
+		CreateJob(gomock.Any(), gomock.Eq(db.CreateJobParams{
+			Kind:        testTask.Kind,
+			Payload:     []byte(`{"value":"hello"}`),
+			MaxAttempts: testTask.MaxAttempts,
+			RunAt:       runAt,
+		})).
+		Times(1).
+		Return(db.Job{}, nil)
+
+	_, err := testTask.EnqueueAt(context.Background(), store, testPayload{Value: "hello"}, runAt)
+	require.NoError(t, err)
+}
+
+func TestRunNext(t *testing.T) {
+	now := time.Now()
+	handlerErr := errors.New("temporary failure")
+
+	testCases := []struct {
+		name       string
+		job        db.Job
+		handler    func(ctx context.Context, payload testPayload) error
+		buildStubs func(store *mockdb.MockStore, job db.Job)
+	}{
+		{
+			name: "OK",
+			job:  randomJob(t, 1),
+			handler: func(ctx context.Context, payload testPayload) error {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().
+					CompleteJob(gomock.Any(), gomock.Eq(db.CompleteJobParams{
+						ID:          job.ID,
+						Attempts:    job.Attempts,
+						LockedUntil: job.LockedUntil,
+					})).
+					Times(1)
+			},
+		},
+		{
+			name: "Retry",
+			job:  randomJob(t, 2),
+			handler: func(ctx context.Context, payload testPayload) error {
+				return handlerErr
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().
+					RetryJob(gomock.Any(), gomock.Eq(db.RetryJobParams{
+						ID:          job.ID,
+						Attempts:    job.Attempts,
+						LockedUntil: job.LockedUntil,
+						RunAt:       now.Add(Backoff(2)),
+						LastError:   handlerErr.Error(),
+					})).
+					Times(1)
+			},
+		},
+		{
+			name: "LastAttempt",
+			job:  randomJob(t, 3),
+			handler: func(ctx context.Context, payload testPayload) error {
+				return handlerErr
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().
+					KillJob(gomock.Any(), gomock.Eq(db.KillJobParams{
+						ID:          job.ID,
+						Attempts:    job.Attempts,
+						LockedUntil: job.LockedUntil,
+						LastError:   handlerErr.Error(),
+					})).
+					Times(1)
+			},
+		},
+		{
+			name: "PermanentError",
+			job:  randomJob(t, 1),
+			handler: func(ctx context.Context, payload testPayload) error {
+				return fmt.Errorf("%w: bad payload", ErrPermanent)
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().RetryJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Panic",
+			job:  randomJob(t, 1),
+			handler: func(ctx context.Context, payload testPayload) error {
+				panic("boom")
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().RetryJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+		},
+		{
+			name: "UndecodablePayload",
+			job: func() db.Job {
+				job := randomJob(t, 1)
+				job.Payload = []byte(`"not an object"`)
+				return job
+			}(),
+			handler: func(ctx context.Context, payload testPayload) error {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+		},
+		{
+			name: "UnknownKind",
+			job: func() db.Job {
+				job := randomJob(t, 1)
+				job.Kind = "unknown"
+				return job
+			}(),
+			handler: func(ctx context.Context, payload testPayload) error {
+				t.Fatal("handler of another kind must not run")
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+		},
+		{
+			name: "LeaseExpiredOnLastAttempt",
+			job:  randomJob(t, 4),
+			handler: func(ctx context.Context, payload testPayload) error {
+				t.Fatal("a job out of attempts must not run")
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+
+			store.EXPECT().
+				ClaimJobs(gomock.Any(), gomock.Eq(db.ClaimJobsParams{
+					LockedUntil: now.Add(leaseDuration),
+					Now:         now,
+					BatchSize:   1,
+				})).
+				Times(1).
+				Return([]db.Job{testCase.job}, nil)
+			testCase.buildStubs(store, testCase.job)
+
+			runner := New(store, 1, time.Second)
+			Handle(runner, testTask, testCase.handler)
+
+			ran, err := runner.RunNext(context.Background(), now)
+			require.NoError(t, err)
+			require.True(t, ran)
+		})
+	}
+}
+
+func TestRunNextLeaseLost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	now := time.Now()
+	job := randomJob(t, 1)
+	store.EXPECT().ClaimJobs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Job{job}, nil)
+	store.EXPECT().CompleteJob(gomock.Any(), gomock.Any()).Times(1).Return(db.Job{}, sql.ErrNoRows)
+
+	runner := New(store, 1, time.Second)
+	Handle(runner, testTask, func(ctx context.Context, payload testPayload) error {
+		return nil
+	})
+
+	ran, err := runner.RunNext(context.Background(), now)
+	require.ErrorIs(t, err, ErrLeaseLost)
+	require.True(t, ran)
+}
+
+func TestRunNextNoJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ClaimJobs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Job{}, nil)
+
+	ran, err := New(store, 1, time.Second).RunNext(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.False(t, ran)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 10*time.Second, Backoff(1))
+	require.Equal(t, 20*time.Second, Backoff(2))
+	require.Equal(t, 40*time.Second, Backoff(3))
+	require.Equal(t, time.Hour, Backoff(20))
+}